@@ -1,16 +1,117 @@
 package main
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
 	"github.com/aemengo/gswt/controller"
+	"github.com/aemengo/gswt/model"
+	"github.com/aemengo/gswt/service"
 	"github.com/aemengo/gswt/utils"
 	"github.com/rivo/tview"
+	"golang.org/x/term"
+	"io"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
 func main() {
+	var (
+		formatName = flag.String("format", "", fmt.Sprintf("format of the test output, one of: %s (default detected from the output)", strings.Join(model.FormatNames(), ", ")))
+		compare    = flag.String("compare", "", "saved output of an earlier go test -bench run to compare benchmarks to")
+		file       = flag.String("file", "", "file to read the test output from, such as a JUnit XML report (default stdin)")
+		junit      = flag.String("junit", "", "file to write a JUnit XML report of the test results to")
+		noTUI      = flag.Bool("no-tui", false, "print a report of the failures instead of opening the terminal UI (default when stdout isn't a terminal)")
+		watch      = flag.Bool("watch", false, "run go test with the arguments given, then again for the packages affected each time a .go file is saved")
+	)
+
+	flag.Parse()
+
+	var format model.Format
+	if *formatName != "" {
+		var ok bool
+		format, ok = model.LookupFormat(*formatName)
+		expectNoError(fmt.Errorf("unknown format '%s', expected one of: %s", *formatName, strings.Join(model.FormatNames(), ", ")), !ok)
+	}
+
+	var baselineBenchmarks []model.Benchmark
+	if *compare != "" {
+		var err error
+		baselineBenchmarks, err = model.BenchmarksFromFile(*compare)
+		expectNoError(err)
+	}
+
+	var stdin io.Reader = os.Stdin
+	if *file != "" {
+		f, err := os.Open(*file)
+		expectNoError(err)
+		defer f.Close()
+
+		stdin = f
+	}
+
+	var (
+		goTest   *service.GoTest
+		stderr   bytes.Buffer
+		headless = *noTUI || !isTerminal(os.Stdout)
+	)
+
+	expectNoError(fmt.Errorf("--watch needs a terminal to show the results in"), *watch && headless)
+
+	// go test is run with the arguments after --, like: go-swt -- ./... -run X
+	if flag.NArg() != 0 || *watch {
+		if headless {
+			goTest = service.NewGoTest(flag.Args(), os.Stderr)
+		} else {
+			// stderr can't be shown while the UI is open,
+			// so it is held on to until the UI is closed
+			goTest = service.NewGoTest(flag.Args(), &stderr)
+		}
+
+		var err error
+		stdin, err = goTest.Start()
+		expectNoError(err)
+
+		// go test has a process group of its own, for it to be stopped
+		// from the UI, so an interrupt from the terminal is passed on
+		interrupts := make(chan os.Signal, 1)
+		signal.Notify(interrupts, os.Interrupt)
+		go func() {
+			for range interrupts {
+				goTest.Interrupt()
+			}
+		}()
+	}
+
+	var junitReport io.Writer
+	if *junit != "" {
+		f, err := os.Create(*junit)
+		expectNoError(err)
+		defer f.Close()
+
+		junitReport = f
+	}
+
+	if headless {
+		ctrl := controller.NewHeadlessController(os.Stdout, stdin, format, baselineBenchmarks, junitReport)
+
+		failed, err := ctrl.Run()
+		expectNoError(err)
+
+		if goTest != nil {
+			os.Exit(goTest.Wait())
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+		return
+	}
+
 	dir, err := os.UserHomeDir()
 	expectNoError(err)
 
@@ -26,10 +127,25 @@ func main() {
 		app    = tview.NewApplication()
 	)
 
-	ctrl := controller.NewCLController(app, logger, os.Stdin)
+	ctrl := controller.NewCLController(app, logger, stdin, format, baselineBenchmarks, junitReport, goTest)
+	if *watch {
+		ctrl.Watch(service.NewWatcher(logger, goTest, time.Second))
+	}
 
 	err = ctrl.Run()
 	expectNoError(err)
+
+	if goTest != nil {
+		exitCode := goTest.Stop()
+		os.Stderr.Write(stderr.Bytes())
+		os.Exit(exitCode)
+	}
+}
+
+// isTerminal reports whether the file is a terminal, rather than a pipe,
+// a file, or a device that isn't a terminal, like /dev/null
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
 }
 
 func expectNoError(err error, cond ...bool) {
@@ -1,68 +1,158 @@
 package controller
 
 import (
+	"fmt"
 	"github.com/aemengo/gswt/model"
+	"github.com/aemengo/gswt/service"
 	"github.com/aemengo/gswt/utils"
 	"github.com/aemengo/gswt/view"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"io"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 )
 
+// stopGracePeriod is how long go test has to print where its tests
+// were once it is asked to stop, before it is killed
+const stopGracePeriod = 5 * time.Second
+
 type CLController struct {
-	app           *tview.Application
-	stdin         io.Reader
-	testSuiteChan chan model.TestSuite
-	lineChan      chan string
-	doneChan      chan bool
-	testsView     *view.Tests
-	logger        *log.Logger
-	logs          model.Logs
+	app              *tview.Application
+	stdin            io.Reader
+	format           model.Format
+	junit            io.Writer
+	goTest           *service.GoTest
+	watcher          *service.Watcher
+	testSuiteChan    chan model.TestSuite
+	buildFailureChan chan model.BuildFailure
+	packageChan      chan model.Package
+	benchmarkChan    chan model.Benchmark
+	lineChan         chan string
+	doneChan         chan bool
+	rerunChan        chan model.Step
+	testsView        *view.Tests
+	logger           *log.Logger
+	logs             model.Logs
 
 	startTime time.Time
 	endTime   time.Time
+
+	// running is the go test that was started last, be it
+	// goTest, a rerun of failed tests, or a run for a change
+	running *service.GoTest
+
+	// stopped tells a rerun that is run in turns not to start the next one
+	stopped bool
+
+	// mu guards the logs, the go test that is running, and the state of
+	// handleEvents, which the handlers of keys share with its loop
+	mu sync.Mutex
 }
 
-func NewCLController(app *tview.Application, logger *log.Logger, stdin io.Reader) *CLController {
+// NewCLController parses stdin in the given format, or one detected from
+// it when the format is empty. A JUnit report of the results is written
+// to junit once parsing is done, unless it is nil. When go-swt started
+// go test itself, stdin is its output and goTest is the process.
+func NewCLController(app *tview.Application, logger *log.Logger, stdin io.Reader, format model.Format, baselineBenchmarks []model.Benchmark, junit io.Writer, goTest *service.GoTest) *CLController {
 	return &CLController{
-		app:           app,
-		logger:        logger,
-		stdin:         stdin,
-		testSuiteChan: make(chan model.TestSuite, 1),
-		lineChan:      make(chan string, 1),
-		doneChan:      make(chan bool, 1),
-		testsView:     view.NewTests(),
-		startTime:     time.Now(),
+		app:              app,
+		logger:           logger,
+		stdin:            stdin,
+		format:           format,
+		junit:            junit,
+		goTest:           goTest,
+		running:          goTest,
+		testSuiteChan:    make(chan model.TestSuite, 1),
+		buildFailureChan: make(chan model.BuildFailure, 1),
+		packageChan:      make(chan model.Package, 1),
+		benchmarkChan:    make(chan model.Benchmark, 1),
+		lineChan:         make(chan string, 1),
+		doneChan:         make(chan bool, 1),
+		rerunChan:        make(chan model.Step, 1),
+		testsView:        view.NewTests(),
+		startTime:        time.Now(),
 		logs: model.Logs{
 			model.Step{
 				Title:    "go test",
 				Selected: true,
 				Success:  true,
+
+				BaselineBenchmarks: baselineBenchmarks,
 			},
 		},
 	}
 }
 
-func (c *CLController) Run() error {
-	c.testsView.Load(c.app, c.logs, view.ModeParseTestsRunning, view.ModeParseTests, time.Now().Sub(c.startTime), "")
+// Watch runs go test again for the packages that each change
+// that the watcher sees affects, in place of the results so far
+func (c *CLController) Watch(watcher *service.Watcher) {
+	c.watcher = watcher
+}
 
+func (c *CLController) Run() error {
 	go c.handleEvents()
 
-	go model.NewParser(c.testSuiteChan, c.lineChan, c.doneChan).ParseGoTestStdin(c.stdin)
+	go c.parse(c.stdin, c.goTest)
+
+	err := c.app.Run()
+
+	c.mu.Lock()
+	running := c.running
+	c.stopped = true
+	c.mu.Unlock()
 
-	return c.app.Run()
+	if running != nil {
+		running.Stop()
+	}
+
+	return err
+}
+
+func (c *CLController) parse(stdin io.Reader, goTest *service.GoTest) {
+	format := c.format
+
+	if format.NewParser == nil {
+		format, stdin = model.SniffFormat(stdin)
+		c.logger.Printf("detected %s test output", format.Name)
+	}
+
+	parser := format.NewParser(model.ParserChans{
+		TestSuites:    c.testSuiteChan,
+		BuildFailures: c.buildFailureChan,
+		Packages:      c.packageChan,
+		Benchmarks:    c.benchmarkChan,
+		Lines:         c.lineChan,
+		Done:          c.doneChan,
+	})
+
+	err := parser.ParseStdin(stdin)
+	if err != nil {
+		c.logger.Printf("failed to read all of the test output: %s", err)
+	}
+
+	if goTest != nil {
+		c.logger.Printf("go test exited with status %d", goTest.Wait())
+	}
 }
 
 func (c *CLController) handleEvents() {
 	var (
 		mode        = view.ModeParseTestsRunning
 		displayMode = view.ModeParseTests
+		listMode    = view.ModeListFailedTests
 		ticker      = time.NewTicker(250 * time.Millisecond)
 
 		detailText string
 		selection  view.Selection
+		rerunning  bool
+		stopping   bool
+		changeChan chan service.Change
+		changes    []service.Change
 
 		testDuration = func() time.Duration {
 			if !c.endTime.Equal(time.Time{}) {
@@ -73,11 +163,56 @@ func (c *CLController) handleEvents() {
 		}
 	)
 
+	if c.watcher != nil {
+		changeChan = c.watcher.ChangeChan
+	}
+
+	// runs go test again for the changes, showing the
+	// results of that run in place of the ones so far
+	watchRun := func() {
+		change := mergeChanges(changes)
+		changes = nil
+
+		goTest := c.goTest.ForPackages(change.Packages)
+		c.logger.Printf("running go test %s", strings.Join(goTest.Args(), " "))
+
+		stdout, err := goTest.Start()
+		if err != nil {
+			c.logger.Printf("failed to run go test: %s", err)
+			return
+		}
+
+		c.running = goTest
+		c.logs = model.Logs{
+			model.Step{
+				Title:    "go test",
+				Selected: true,
+				Success:  true,
+
+				BaselineBenchmarks: c.logs[0].BaselineBenchmarks,
+			},
+		}
+
+		mode = view.ModeParseTestsRunning
+		ticker = time.NewTicker(250 * time.Millisecond)
+		detailText = ""
+		selection = view.Selection{}
+		c.startTime = time.Now()
+		c.endTime = time.Time{}
+
+		c.testsView.SetMessage(fmt.Sprintf("[darkgray](%s)[-]", changeText(change)))
+		go c.parse(stdout, goTest)
+	}
+
 	// HANDLE USER EVENTS
 	// these are unique because app.Draw() cannot be called for these
-	// otherwise race conditions will happen
+	// otherwise race conditions will happen. They run on the goroutine
+	// of the app, so they hold c.mu for the state they share with the loop
 	c.testsView.SetHandlers(
 		func(key tcell.Key) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
 			if key == tcell.KeyTab {
 				c.app.Suspend(func() {
 					utils.ShowLogsInEditor(c.logs)
@@ -85,6 +220,9 @@ func (c *CLController) handleEvents() {
 			}
 		},
 		func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
 			switch displayMode {
 			case view.ModeParseTests:
 				displayMode = view.ModeParseTestsFuller
@@ -92,43 +230,382 @@ func (c *CLController) handleEvents() {
 				displayMode = view.ModeParseTests
 			}
 
-			c.testsView.Load(c.app, c.logs, mode, displayMode, testDuration(), detailText, selection)
+			c.testsView.Load(c.app, c.logs, mode, displayMode, listMode, testDuration(), detailText, selection)
 		},
 		func(id int) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
 			c.logs.Toggle(id)
 			selection = view.Selection{Type: view.SelectionTypeID, Value: id}
-			c.testsView.Load(c.app, c.logs, mode, displayMode, testDuration(), detailText, selection)
+			c.testsView.Load(c.app, c.logs, mode, displayMode, listMode, testDuration(), detailText, selection)
 		},
 		func(txt string, row int) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
 			detailText = txt
 			selection = view.Selection{Type: view.SelectionTypeRow, Value: row}
 			c.testsView.UpdateDetail(detailText)
+		},
+		func(r rune, id int) bool {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			var toggledMode int
+
+			switch r {
+			case 'c':
+				switch {
+				case c.goTest == nil:
+					c.testsView.SetMessage("[yellow](Stopping tests needs go-swt to run go test, like go-swt -- ./...)[-]")
+				case !c.running.Running():
+					c.testsView.SetMessage("[yellow](go test isn't running)[-]")
+				default:
+					c.logger.Printf("stopping go test %s", strings.Join(c.running.Args(), " "))
+					c.running.Quit(stopGracePeriod)
+					c.stopped = true
+					stopping = true
+					c.testsView.SetMessage("[yellow](Stopping go test...)[-]")
+				}
+
+				c.testsView.Load(c.app, c.logs, mode, displayMode, listMode, testDuration(), detailText, selection)
+				return true
+			case 'e':
+				err := c.showSourceInEditor(detailText)
+				if err != nil {
+					c.testsView.SetMessage(fmt.Sprintf("[yellow](%s)[-]", tview.Escape(err.Error())))
+				}
+
+				c.testsView.Load(c.app, c.logs, mode, displayMode, listMode, testDuration(), detailText, selection)
+				return true
+			case 'f', 'F':
+				if r == 'F' {
+					// every failed test, whatever is selected
+					id = 0
+				}
+
+				if mode != view.ModeParseTestsFinished || rerunning {
+					c.testsView.SetMessage("[yellow](Wait for go test to finish to rerun tests)[-]")
+				} else {
+					var msg string
+					msg, rerunning = c.startRerun(id)
+					c.testsView.SetMessage(msg)
+				}
+
+				c.testsView.Load(c.app, c.logs, mode, displayMode, listMode, testDuration(), detailText, selection)
+				return true
+			case 's':
+				toggledMode = view.ModeListSkippedTests
+			case 't':
+				toggledMode = view.ModeListSlowestTests
+			case 'r':
+				toggledMode = view.ModeListDataRaces
+			case 'b':
+				toggledMode = view.ModeListBenchmarks
+			case 'd':
+				toggledMode = view.ModeListDiffs
+			default:
+				return false
+			}
+
+			if listMode == toggledMode {
+				listMode = view.ModeListFailedTests
+			} else {
+				listMode = toggledMode
+			}
+
+			c.testsView.Load(c.app, c.logs, mode, displayMode, listMode, testDuration(), detailText)
+			return true
 		})
 
+	// the table of tests takes the handlers as it is built,
+	// so they are set before it is built for the first time
+	c.mu.Lock()
+	c.testsView.Load(c.app, c.logs, mode, displayMode, listMode, testDuration(), detailText)
+	c.mu.Unlock()
+
 	// HANDLE AUTOMATIC EVENTS
 	for {
 		select {
 
 		// when ticker goes off
 		case <-ticker.C:
+			c.mu.Lock()
 			c.testsView.UpdateStatus(mode, c.logs, testDuration())
 
 		// when parsing updates
 		case line := <-c.lineChan:
+			c.mu.Lock()
 			c.logs[0].Lines = append(c.logs[0].Lines, line)
 		case testSuite := <-c.testSuiteChan:
-			c.logs[0].TestSuites = append(c.logs[0].TestSuites, testSuite)
-			c.testsView.Load(c.app, c.logs, mode, displayMode, testDuration(), detailText, selection)
+			c.mu.Lock()
+			c.logs[0].AddTestSuite(testSuite)
+			c.testsView.Load(c.app, c.logs, mode, displayMode, listMode, testDuration(), detailText, selection)
+		case buildFailure := <-c.buildFailureChan:
+			c.mu.Lock()
+			c.logs[0].BuildFailures = append(c.logs[0].BuildFailures, buildFailure)
+			c.testsView.Load(c.app, c.logs, mode, displayMode, listMode, testDuration(), detailText, selection)
+		case benchmark := <-c.benchmarkChan:
+			c.mu.Lock()
+			c.logs[0].Benchmarks = append(c.logs[0].Benchmarks, benchmark)
+			c.testsView.Load(c.app, c.logs, mode, displayMode, listMode, testDuration(), detailText, selection)
+		case pkg := <-c.packageChan:
+			c.mu.Lock()
+			c.logs[0].AddPackage(pkg)
+			c.testsView.Load(c.app, c.logs, mode, displayMode, listMode, testDuration(), detailText, selection)
+
+		// when a rerun finishes
+		case step := <-c.rerunChan:
+			c.mu.Lock()
+			rerunning = false
+			fixed, stillFailing := c.logs.FinishRerun(step)
+			c.testsView.SetMessage(fmt.Sprintf("[yellow](Rerun: %d fixed, %d still failing)[-]", fixed, stillFailing))
+
+			if stopping {
+				stopping = false
+				c.testsView.SetMessage(fmt.Sprintf("[yellow](Stopped rerun: %d fixed, %d still failing)[-]", fixed, stillFailing))
+			}
+
+			if len(changes) != 0 {
+				watchRun()
+			}
+			c.testsView.Load(c.app, c.logs, mode, displayMode, listMode, testDuration(), detailText, selection)
+
+		// when a watched file is saved, which is handled once
+		// nothing is running, for the results not to be mixed up
+		case change := <-changeChan:
+			c.mu.Lock()
+			changes = append(changes, change)
+			if mode == view.ModeParseTestsRunning || rerunning {
+				c.mu.Unlock()
+				continue
+			}
+
+			watchRun()
+			c.testsView.Load(c.app, c.logs, mode, displayMode, listMode, testDuration(), detailText, selection)
 
 		// when parsing finishes
 		case <-c.doneChan:
+			c.mu.Lock()
+
+			// what was sent before parsing finished is handled first,
+			// so that the report has all of it
+			if len(c.testSuiteChan)+len(c.buildFailureChan)+len(c.packageChan)+len(c.benchmarkChan)+len(c.lineChan) != 0 {
+				c.doneChan <- true
+				c.mu.Unlock()
+				continue
+			}
+
 			mode = view.ModeParseTestsFinished
 			ticker.Stop()
 			c.endTime = time.Now()
-			c.testsView.Load(c.app, c.logs, mode, displayMode, testDuration(), detailText, selection)
+
+			if c.junit != nil {
+				err := c.logs.WriteJUnit(c.junit)
+				if err != nil {
+					c.logger.Printf("failed to write junit report: %s", err)
+				}
+
+				// the report is of the first run only, when watching
+				c.junit = nil
+			}
+
+			if stopping {
+				stopping = false
+				c.testsView.SetMessage("[yellow](Stopped go test)[-]")
+			}
+
+			if len(changes) != 0 && !rerunning {
+				watchRun()
+			}
+			c.testsView.Load(c.app, c.logs, mode, displayMode, listMode, testDuration(), detailText, selection)
 		}
 
+		c.mu.Unlock()
 		c.app.Draw()
 	}
 
 }
+
+// showSourceInEditor opens $EDITOR at the file.go:57 location in the
+// text of the selected row. The file is looked for in the directories of
+// the failed tests' packages, since go test names it relative to them.
+func (c *CLController) showSourceInEditor(txt string) error {
+	file, line, ok := view.SourceLocation(txt)
+	if !ok {
+		return fmt.Errorf("no file.go:line location on the selected row to open")
+	}
+
+	path, err := c.sourcePath(file)
+	if err != nil {
+		return err
+	}
+
+	c.app.Suspend(func() {
+		err = utils.ShowSourceInEditor(path, line)
+	})
+
+	return err
+}
+
+func (c *CLController) sourcePath(file string) (string, error) {
+	if filepath.IsAbs(file) {
+		_, err := os.Stat(file)
+		return file, err
+	}
+
+	for _, pkg := range c.logs.FailedPackageNames() {
+		dir, err := model.PackageDir(pkg)
+		if err != nil {
+			c.logger.Printf("failed to find directory of package %s: %s", pkg, err)
+			continue
+		}
+
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	if _, err := os.Stat(file); err == nil {
+		return filepath.Abs(file)
+	}
+
+	return "", fmt.Errorf("unable to find %s in the packages of the failed tests", file)
+}
+
+// startRerun runs go test again for the failed test with the given id,
+// or for every failed test when there is no such test, and returns what
+// to tell about it and whether it started
+func (c *CLController) startRerun(id int) (string, bool) {
+	if c.goTest == nil {
+		return "[yellow](Rerunning tests needs go-swt to run go test, like go-swt -- ./...)[-]", false
+	}
+
+	names := c.logs.StartRerun(id)
+	if len(names) == 0 {
+		return "[yellow](No failed tests to rerun)[-]", false
+	}
+
+	patterns := model.RunPatterns(names)
+
+	goTest, stdout, err := c.startRerunOf(patterns[0])
+	if err != nil {
+		c.logs.FinishRerun(model.Step{})
+		return "[red](Failed to rerun go test)[-]", false
+	}
+
+	c.running = goTest
+	c.stopped = false
+	go c.rerun(stdout, goTest, patterns[1:])
+
+	if len(names) == 1 {
+		return "[yellow](Rerunning 1 test...)[-]", true
+	}
+
+	return fmt.Sprintf("[yellow](Rerunning %d tests...)[-]", len(names)), true
+}
+
+// startRerunOf starts go test for the tests that match the -run pattern
+func (c *CLController) startRerunOf(pattern string) (*service.GoTest, io.Reader, error) {
+	goTest := c.goTest.Rerun(pattern)
+	c.logger.Printf("rerunning go test %s", strings.Join(goTest.Args(), " "))
+
+	stdout, err := goTest.Start()
+	if err != nil {
+		c.logger.Printf("failed to rerun go test: %s", err)
+		return nil, nil, err
+	}
+
+	return goTest, stdout, nil
+}
+
+// rerun parses all of the output of go test once it exits, running it
+// again for each of the other patterns in turn unless it is stopped,
+// for the results to be merged into the logs
+func (c *CLController) rerun(stdout io.Reader, goTest *service.GoTest, patterns []string) {
+	var (
+		id   = 1
+		step model.Step
+	)
+
+	for {
+		err := model.ScanLines(stdout, func(line string) {
+			step.Lines = append(step.Lines, line)
+		})
+		if err != nil {
+			c.logger.Printf("failed to read all of the output of the rerun: %s", err)
+		}
+
+		c.logger.Printf("go test exited with status %d", goTest.Wait())
+
+		if len(patterns) == 0 {
+			break
+		}
+
+		c.mu.Lock()
+		if c.stopped {
+			c.mu.Unlock()
+			break
+		}
+
+		goTest, stdout, err = c.startRerunOf(patterns[0])
+		if err != nil {
+			c.mu.Unlock()
+			break
+		}
+
+		c.running = goTest
+		patterns = patterns[1:]
+		c.mu.Unlock()
+	}
+
+	format := c.format
+	if format.NewParser == nil {
+		format = model.DetectFormat(step.Lines)
+	}
+
+	format.NewParser(model.ParserChans{}).ParseStep(&id, &step)
+	c.rerunChan <- step
+}
+
+// mergeChanges combines the changes that were saved up while go test ran
+func mergeChanges(changes []service.Change) service.Change {
+	var (
+		merged service.Change
+		seen   = map[string]bool{}
+	)
+
+	for _, change := range changes {
+		for _, file := range change.Files {
+			if !seen[file] {
+				seen[file] = true
+				merged.Files = append(merged.Files, file)
+			}
+		}
+
+		for _, pkg := range change.Packages {
+			if !seen[pkg] {
+				seen[pkg] = true
+				merged.Packages = append(merged.Packages, pkg)
+			}
+		}
+	}
+
+	return merged
+}
+
+// changeText tells what was saved, for the status bar
+func changeText(change service.Change) string {
+	txt := filepath.Base(change.Files[0])
+	if len(change.Files) > 1 {
+		txt = txt + fmt.Sprintf(" and %d more", len(change.Files)-1)
+	}
+
+	if len(change.Packages) == 1 {
+		return txt + " changed, running 1 package"
+	}
+
+	return txt + fmt.Sprintf(" changed, running %d packages", len(change.Packages))
+}
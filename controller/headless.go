@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"github.com/aemengo/gswt/model"
+	"github.com/aemengo/gswt/view"
+	"io"
+	"time"
+)
+
+// HeadlessController parses stdin the way CLController does, but
+// prints a report of what failed instead of opening the terminal UI
+type HeadlessController struct {
+	stdout io.Writer
+	stdin  io.Reader
+	format model.Format
+	junit  io.Writer
+	logs   model.Logs
+
+	startTime time.Time
+}
+
+// NewHeadlessController parses stdin in the given format, or one detected
+// from it when the format is empty, and writes its report to stdout.
+// A JUnit report of the results is written to junit too, unless it is nil.
+func NewHeadlessController(stdout io.Writer, stdin io.Reader, format model.Format, baselineBenchmarks []model.Benchmark, junit io.Writer) *HeadlessController {
+	return &HeadlessController{
+		stdout:    stdout,
+		stdin:     stdin,
+		format:    format,
+		junit:     junit,
+		startTime: time.Now(),
+		logs: model.Logs{
+			model.Step{
+				Title:   "go test",
+				Success: true,
+
+				BaselineBenchmarks: baselineBenchmarks,
+			},
+		},
+	}
+}
+
+// Run reports whether anything failed, once all of stdin is read
+func (c *HeadlessController) Run() (bool, error) {
+	var (
+		id   = 1
+		step = &c.logs[0]
+	)
+
+	err := model.ScanLines(c.stdin, func(line string) {
+		step.Lines = append(step.Lines, line)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	format := c.format
+	if format.NewParser == nil {
+		format = model.DetectFormat(step.Lines)
+	}
+
+	format.NewParser(model.ParserChans{}).ParseStep(&id, step)
+
+	if c.junit != nil {
+		err = c.logs.WriteJUnit(c.junit)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	err = view.WriteSummary(c.stdout, c.logs, time.Now().Sub(c.startTime))
+	if err != nil {
+		return false, err
+	}
+
+	return c.logs.Failed(), nil
+}
@@ -10,4 +10,5 @@ require (
 	github.com/rivo/tview v0.0.0-20210909154944-f7430b878d17
 	github.com/sclevine/spec v1.4.0
 	golang.org/x/oauth2 v0.0.0-20210427180440-81ed05c6b58c
+	golang.org/x/term v0.0.0-20210220032956-6a3ed077a48d
 )
@@ -0,0 +1,113 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Assertion is a failed testify assertion, from the block of
+// labeled fields that testify prints beneath the failing call
+type Assertion struct {
+	// Trace is where the assertion was made, as "file:line",
+	// followed by each call that led to it
+	Trace []string
+
+	Error    string
+	Expected string
+	Actual   string
+	Diff     []string
+
+	// Test is the name of the test, which for a testify
+	// suite is that of the suite and the method
+	Test     string
+	Messages string
+
+	// Line is where the block starts among the lines of the run
+	Line  int
+	Lines []string
+}
+
+var (
+	assertionFieldMatcher    = regexp.MustCompile(`^\s*\t(Error Trace|Error|Test|Messages):\s*\t(.*)$`)
+	assertionValueMatcher    = regexp.MustCompile(`^\s*\t\s+\t(.*)$`)
+	assertionExpectedMatcher = regexp.MustCompile(`^expected\s*: (.*)$`)
+	assertionActualMatcher   = regexp.MustCompile(`^actual\s*: (.*)$`)
+)
+
+// parseAssertions finds the testify assertion blocks among the lines of a run
+func parseAssertions(lines []string) []Assertion {
+	var (
+		assertions []Assertion
+		field      string
+		current    *Assertion
+	)
+
+	for i, line := range lines {
+		fieldMatches := assertionFieldMatcher.FindStringSubmatch(line)
+		if len(fieldMatches) == 3 {
+			if fieldMatches[1] == "Error Trace" {
+				assertions = append(assertions, Assertion{Line: i})
+				current = &assertions[len(assertions)-1]
+			}
+
+			if current == nil {
+				continue
+			}
+
+			field = fieldMatches[1]
+			current.addValue(field, fieldMatches[2])
+			current.Lines = append(current.Lines, line)
+			continue
+		}
+
+		valueMatches := assertionValueMatcher.FindStringSubmatch(line)
+		if current != nil && len(valueMatches) == 2 {
+			current.addValue(field, valueMatches[1])
+			current.Lines = append(current.Lines, line)
+			continue
+		}
+
+		current = nil
+	}
+
+	return assertions
+}
+
+// addValue adds a line to one of the fields. The error of a comparison
+// goes on to the values that were compared and the diff between them.
+func (a *Assertion) addValue(field string, value string) {
+	switch field {
+	case "Error Trace":
+		if trace := strings.TrimSpace(value); trace != "" {
+			a.Trace = append(a.Trace, trace)
+		}
+	case "Test":
+		a.Test = value
+	case "Messages":
+		a.Messages = joinLine(a.Messages, value)
+	case "Error":
+		expectedMatches := assertionExpectedMatcher.FindStringSubmatch(value)
+		actualMatches := assertionActualMatcher.FindStringSubmatch(value)
+
+		switch {
+		case a.Diff != nil:
+			a.Diff = append(a.Diff, value)
+		case value == "Diff:":
+			a.Diff = []string{}
+		case len(expectedMatches) == 2:
+			a.Expected = expectedMatches[1]
+		case len(actualMatches) == 2:
+			a.Actual = actualMatches[1]
+		case strings.TrimSpace(value) != "":
+			a.Error = joinLine(a.Error, strings.TrimSpace(value))
+		}
+	}
+}
+
+func joinLine(text string, line string) string {
+	if text == "" {
+		return line
+	}
+
+	return text + "\n" + line
+}
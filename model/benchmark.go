@@ -0,0 +1,338 @@
+package model
+
+import (
+	"bufio"
+	"math"
+	"os"
+	"sort"
+)
+
+// significanceLevel is the p-value below which a change
+// between two runs of a benchmark is taken to be real
+const significanceLevel = 0.05
+
+// Benchmark is every result go test printed for a benchmark,
+// one for each time it ran with -count
+type Benchmark struct {
+	ID      int
+	Name    string
+	Package string
+
+	// Procs is the GOMAXPROCS the benchmark ran with,
+	// which go test appends to its name, as in BenchmarkX-8
+	Procs int
+
+	Failed  bool
+	Samples []BenchmarkSample
+	Lines   []string
+}
+
+// BenchmarkSample is a single result line, such as
+// "BenchmarkX-8  1000  1234 ns/op  56 B/op  2 allocs/op"
+type BenchmarkSample struct {
+	Iterations int
+	Metrics    []Metric
+}
+
+// Metric is a measurement of a benchmark, either one of go test's
+// own or a custom one reported with b.ReportMetric
+type Metric struct {
+	Value float64
+	Unit  string
+}
+
+// BenchmarkStat sums up the values of a metric across samples the way
+// benchstat does, as their mean give or take the largest deviation
+// from it, after leaving out outliers
+type BenchmarkStat struct {
+	Mean      float64
+	Variation float64
+	Count     int
+
+	values []float64
+}
+
+// BenchmarkDelta compares a metric of a benchmark to the same
+// metric of the benchmark from a saved run
+type BenchmarkDelta struct {
+	Unit string
+	Old  BenchmarkStat
+	New  BenchmarkStat
+
+	// Change is the relative change of the mean, as in -0.12 for 12% less
+	Change float64
+	P      float64
+}
+
+// Significant reports whether the change is unlikely to be noise
+func (d BenchmarkDelta) Significant() bool {
+	return d.P < significanceLevel
+}
+
+// Units are the units of the benchmark's metrics, in the order go test printed them
+func (b *Benchmark) Units() []string {
+	var units []string
+
+	for _, sample := range b.Samples {
+		for _, metric := range sample.Metrics {
+			if !containsString(units, metric.Unit) {
+				units = append(units, metric.Unit)
+			}
+		}
+	}
+
+	return units
+}
+
+// Stat sums up the benchmark's values for the given unit
+func (b *Benchmark) Stat(unit string) BenchmarkStat {
+	var values []float64
+
+	for _, sample := range b.Samples {
+		for _, metric := range sample.Metrics {
+			if metric.Unit == unit {
+				values = append(values, metric.Value)
+			}
+		}
+	}
+
+	return newBenchmarkStat(values)
+}
+
+// Compare is how each metric of the benchmark changed from
+// the same benchmark in a saved run, for units both have
+func (b *Benchmark) Compare(old Benchmark) []BenchmarkDelta {
+	var deltas []BenchmarkDelta
+
+	for _, unit := range b.Units() {
+		if !containsString(old.Units(), unit) {
+			continue
+		}
+
+		var (
+			oldStat = old.Stat(unit)
+			newStat = b.Stat(unit)
+			change  float64
+		)
+
+		if oldStat.Mean != 0 {
+			change = (newStat.Mean - oldStat.Mean) / oldStat.Mean
+		}
+
+		deltas = append(deltas, BenchmarkDelta{
+			Unit:   unit,
+			Old:    oldStat,
+			New:    newStat,
+			Change: change,
+			P:      mannWhitneyUTest(oldStat.values, newStat.values),
+		})
+	}
+
+	return deltas
+}
+
+// BenchmarksFromFile reads the benchmarks out of the saved
+// output of an earlier go test run, either verbose or json
+func BenchmarksFromFile(path string) ([]Benchmark, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		id      = 1
+		step    = Step{}
+		scanner = bufio.NewScanner(f)
+	)
+
+	for scanner.Scan() {
+		step.Lines = append(step.Lines, scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	NewParser(nil, nil, nil, nil, nil, nil).ParseStep(&id, &step)
+	return step.Benchmarks, nil
+}
+
+func newBenchmarkStat(values []float64) BenchmarkStat {
+	if len(values) == 0 {
+		return BenchmarkStat{}
+	}
+
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+
+	var (
+		q1   = quantile(sorted, 0.25)
+		q3   = quantile(sorted, 0.75)
+		low  = q1 - 1.5*(q3-q1)
+		high = q3 + 1.5*(q3-q1)
+		kept []float64
+		sum  float64
+	)
+
+	for _, value := range sorted {
+		if value >= low && value <= high {
+			kept = append(kept, value)
+			sum = sum + value
+		}
+	}
+
+	stat := BenchmarkStat{
+		Mean:   sum / float64(len(kept)),
+		Count:  len(kept),
+		values: kept,
+	}
+
+	if stat.Mean == 0 {
+		return stat
+	}
+
+	for _, value := range kept {
+		stat.Variation = math.Max(stat.Variation, math.Abs(value-stat.Mean)/stat.Mean)
+	}
+
+	return stat
+}
+
+// quantile interpolates between the closest of the sorted values
+func quantile(sorted []float64, q float64) float64 {
+	var (
+		pos  = q * float64(len(sorted)-1)
+		i    = int(pos)
+		frac = pos - float64(i)
+	)
+
+	if i+1 >= len(sorted) {
+		return sorted[i]
+	}
+
+	return sorted[i] + frac*(sorted[i+1]-sorted[i])
+}
+
+// mannWhitneyUTest is the two-sided p-value of the Mann-Whitney U test,
+// which benchstat uses to tell whether two sets of samples differ.
+// Small sets without ties get the exact p-value, and
+// everything else the normal approximation of it.
+func mannWhitneyUTest(xs, ys []float64) float64 {
+	n1, n2 := len(xs), len(ys)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type value struct {
+		v     float64
+		fromX bool
+	}
+
+	var all []value
+	for _, x := range xs {
+		all = append(all, value{x, true})
+	}
+	for _, y := range ys {
+		all = append(all, value{y, false})
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].v < all[j].v
+	})
+
+	var (
+		rankSum float64
+		tieSum  float64
+		hasTies bool
+	)
+
+	// tied values share the average of their ranks
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].fromX {
+				rankSum = rankSum + rank
+			}
+		}
+
+		if t := float64(j - i); t > 1 {
+			hasTies = true
+			tieSum = tieSum + t*t*t - t
+		}
+
+		i = j
+	}
+
+	var (
+		u1 = rankSum - float64(n1*(n1+1))/2
+		u  = math.Min(u1, float64(n1*n2)-u1)
+	)
+
+	if !hasTies && n1+n2 <= 50 {
+		return math.Min(1, 2*mannWhitneyUExactCDF(n1, n2, int(u)))
+	}
+
+	var (
+		n     = float64(n1 + n2)
+		mean  = float64(n1*n2) / 2
+		sigma = math.Sqrt(float64(n1*n2) / 12 * ((n + 1) - tieSum/(n*(n-1))))
+	)
+
+	if sigma == 0 {
+		return 1
+	}
+
+	z := (mean - u - 0.5) / sigma
+	if z < 0 {
+		return 1
+	}
+
+	return math.Min(1, math.Erfc(z/math.Sqrt2))
+}
+
+// mannWhitneyUExactCDF is the chance of U being at most u when both
+// sets come from the same distribution, by counting the orderings
+// of the two sets that give each value of U
+func mannWhitneyUExactCDF(n1, n2, u int) float64 {
+	// counts[i][j][k] is the number of orderings of i values
+	// from one set and j from the other with a U of k
+	counts := make([][][]float64, n1+1)
+	for i := range counts {
+		counts[i] = make([][]float64, n2+1)
+		for j := range counts[i] {
+			counts[i][j] = make([]float64, i*j+1)
+
+			for k := range counts[i][j] {
+				switch {
+				case i == 0 || j == 0:
+					counts[i][j][k] = 1
+				default:
+					// the largest value is either from the first set,
+					// beating all j of the second, or it is from the second
+					if k >= j && k-j <= (i-1)*j {
+						counts[i][j][k] = counts[i][j][k] + counts[i-1][j][k-j]
+					}
+					if k <= i*(j-1) {
+						counts[i][j][k] = counts[i][j][k] + counts[i][j-1][k]
+					}
+				}
+			}
+		}
+	}
+
+	var below, total float64
+	for k, count := range counts[n1][n2] {
+		if k <= u {
+			below = below + count
+		}
+		total = total + count
+	}
+
+	return below / total
+}
@@ -0,0 +1,20 @@
+package model
+
+// BuildFailure is a package that go test could not compile,
+// along with everything the compiler printed about it
+type BuildFailure struct {
+	ID       int
+	Package  string
+	Selected bool
+
+	Lines       []string
+	Diagnostics []Diagnostic
+}
+
+// Diagnostic is a single "file.go:12:3: message" line of compiler output
+type Diagnostic struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
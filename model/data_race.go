@@ -0,0 +1,40 @@
+package model
+
+// DataRace is a report from the race detector about two goroutines
+// using the same memory at once, with at least one of them writing to it
+type DataRace struct {
+	// Test is the name of the test that was running when it happened
+	Test string
+
+	Accesses   []RaceStack
+	Goroutines []RaceStack
+	Lines      []string
+}
+
+// RaceStack is a section of a data race report, which is the stack
+// beneath a header like "Previous write at 0x00c0000182f8 by goroutine 7"
+// or "Goroutine 7 (finished) created at"
+type RaceStack struct {
+	Header string
+	Frames []StackFrame
+}
+
+// StackFrame is a single function call of a stack trace
+type StackFrame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// currentStack is the section that stack frames are being added to,
+// with goroutine creations always coming after the accesses
+func (r *DataRace) currentStack() *RaceStack {
+	switch {
+	case len(r.Goroutines) != 0:
+		return &r.Goroutines[len(r.Goroutines)-1]
+	case len(r.Accesses) != 0:
+		return &r.Accesses[len(r.Accesses)-1]
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,476 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxDiffCells bounds how much work lining up two values can take,
+// past which lines are only compared by what they start and end with
+const maxDiffCells = 1000000
+
+// Comparison is a pair of values that a failed test compared,
+// as found in its output, with each value split into lines
+type Comparison struct {
+	Expected []string
+	Actual   []string
+}
+
+// DiffRow is a line of a side-by-side diff. A line that is only
+// on one side has no spans on the other.
+type DiffRow struct {
+	Expected []DiffSpan
+	Actual   []DiffSpan
+}
+
+// DiffSpan is a part of a line that either is the same on
+// both sides or changed between them
+type DiffSpan struct {
+	Text    string
+	Changed bool
+}
+
+var (
+	ansiMatcher          = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	cmpHeaderMatcher     = regexp.MustCompile(`\(-(\S+) \+(\S+)\):?$`)
+	gomegaActualMatcher  = regexp.MustCompile(`^(?:\[FAILED\] )?Expected$`)
+	gomegaMatcherMatcher = regexp.MustCompile(`^to (equal|be equivalent to|be identical to|match JSON of|match YAML of|match XML of)$`)
+	labeledValueMatcher  = regexp.MustCompile(`(?i)^(expected|want|wanted|actual|got|have)\s*:\s*(.*)$`)
+)
+
+// Comparisons finds the values that the run compared and that didn't match.
+// These come from testify assertions, go-cmp's cmp.Diff, gomega's
+// "Expected ... to equal ..." and values labeled as expected and actual.
+func (r *TestRun) Comparisons() []Comparison {
+	var (
+		comparisons []Comparison
+		lines       = make([]string, len(r.Lines))
+		used        = make([]bool, len(r.Lines))
+	)
+
+	// cmp.Diff sometimes uses non-breaking spaces, to keep its output from being relied on
+	for i, line := range r.Lines {
+		lines[i] = strings.TrimRight(strings.ReplaceAll(ansiMatcher.ReplaceAllString(line, ""), "\u00a0", " "), " ")
+	}
+
+	for _, assertion := range r.Assertions {
+		for i := assertion.Line; i < assertion.Line+len(assertion.Lines) && i < len(used); i++ {
+			used[i] = true
+		}
+
+		switch {
+		case len(assertion.Diff) != 0:
+			comparisons = append(comparisons, unifiedDiffComparison(assertion.Diff))
+		case assertion.Expected != "" || assertion.Actual != "":
+			comparisons = append(comparisons, Comparison{
+				Expected: []string{assertion.Expected},
+				Actual:   []string{assertion.Actual},
+			})
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		if used[i] {
+			continue
+		}
+
+		var (
+			comparison Comparison
+			end        int
+			ok         bool
+		)
+
+		for _, find := range []func([]string, int) (Comparison, int, bool){cmpDiffAt, gomegaComparisonAt, labeledComparisonAt} {
+			comparison, end, ok = find(lines, i)
+			if ok {
+				break
+			}
+		}
+
+		if ok {
+			comparisons = append(comparisons, comparison)
+			i = end - 1
+		}
+	}
+
+	return comparisons
+}
+
+// unifiedDiffComparison takes the two values back out of
+// the unified diff that testify prints for them
+func unifiedDiffComparison(diff []string) Comparison {
+	var comparison Comparison
+
+	for _, line := range diff {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "@@"):
+		case strings.HasPrefix(line, "-"):
+			comparison.Expected = append(comparison.Expected, diffLineText(line))
+		case strings.HasPrefix(line, "+"):
+			comparison.Actual = append(comparison.Actual, diffLineText(line))
+		default:
+			comparison.Expected = append(comparison.Expected, diffLineText(line))
+			comparison.Actual = append(comparison.Actual, diffLineText(line))
+		}
+	}
+
+	return comparison
+}
+
+// cmpDiffAt reads the output of cmp.Diff beneath a header like
+// "mismatch (-want +got):", where the header says which side is which
+func cmpDiffAt(lines []string, start int) (Comparison, int, bool) {
+	headerMatches := cmpHeaderMatcher.FindStringSubmatch(lines[start])
+	if len(headerMatches) != 3 {
+		return Comparison{}, 0, false
+	}
+
+	var (
+		removed, added []string
+		end            = start + 1
+		indent         = -1
+	)
+
+	for ; end < len(lines); end++ {
+		line := lines[end]
+		if strings.TrimSpace(line) == "" || indentOf(line) <= indentOf(lines[start]) {
+			break
+		}
+
+		// the markers are printed to the left of the values
+		if indent == -1 {
+			indent = indentOf(line)
+			if !strings.HasPrefix(strings.TrimSpace(line), "-") && !strings.HasPrefix(strings.TrimSpace(line), "+") {
+				indent = indent - 2
+			}
+		}
+
+		if indent < 0 || len(line) < indent+1 {
+			break
+		}
+
+		text := diffLineText(line[indent:])
+
+		switch line[indent] {
+		case '-':
+			removed = append(removed, text)
+		case '+':
+			added = append(added, text)
+		default:
+			removed = append(removed, text)
+			added = append(added, text)
+		}
+	}
+
+	if len(removed) == 0 && len(added) == 0 {
+		return Comparison{}, 0, false
+	}
+
+	comparison := Comparison{Expected: removed, Actual: added}
+
+	switch strings.ToLower(headerMatches[1]) {
+	case "got", "actual", "have":
+		comparison = Comparison{Expected: added, Actual: removed}
+	}
+
+	return comparison, end, true
+}
+
+// gomegaComparisonAt reads gomega's failure message, which
+// starts with the actual value and ends with the expected one
+func gomegaComparisonAt(lines []string, start int) (Comparison, int, bool) {
+	if !gomegaActualMatcher.MatchString(strings.TrimSpace(lines[start])) {
+		return Comparison{}, 0, false
+	}
+
+	actual, end := indentedValue(lines, start, "")
+	if end >= len(lines) || !gomegaMatcherMatcher.MatchString(strings.TrimSpace(lines[end])) {
+		return Comparison{}, 0, false
+	}
+
+	expected, end := indentedValue(lines, end, "")
+	if len(actual) == 0 || len(expected) == 0 {
+		return Comparison{}, 0, false
+	}
+
+	return Comparison{Expected: expected, Actual: actual}, end, true
+}
+
+// labeledComparisonAt reads a value labeled as expected or actual,
+// like "want: 3", which is followed by the other one
+func labeledComparisonAt(lines []string, start int) (Comparison, int, bool) {
+	firstMatches := labeledValueMatcher.FindStringSubmatch(strings.TrimSpace(lines[start]))
+	if len(firstMatches) != 3 {
+		return Comparison{}, 0, false
+	}
+
+	first, end := indentedValue(lines, start, firstMatches[2])
+	if end >= len(lines) {
+		return Comparison{}, 0, false
+	}
+
+	secondMatches := labeledValueMatcher.FindStringSubmatch(strings.TrimSpace(lines[end]))
+	if len(secondMatches) != 3 || isExpectedLabel(firstMatches[1]) == isExpectedLabel(secondMatches[1]) {
+		return Comparison{}, 0, false
+	}
+
+	second, end := indentedValue(lines, end, secondMatches[2])
+
+	if isExpectedLabel(firstMatches[1]) {
+		return Comparison{Expected: first, Actual: second}, end, true
+	}
+
+	return Comparison{Expected: second, Actual: first}, end, true
+}
+
+func isExpectedLabel(label string) bool {
+	switch strings.ToLower(label) {
+	case "expected", "want", "wanted":
+		return true
+	default:
+		return false
+	}
+}
+
+// indentedValue is the value on the line after its label, if any,
+// and the lines beneath it that are indented further than the label
+func indentedValue(lines []string, start int, value string) ([]string, int) {
+	var (
+		values []string
+		end    = start + 1
+	)
+
+	if value != "" {
+		values = append(values, value)
+	}
+
+	for ; end < len(lines); end++ {
+		if strings.TrimSpace(lines[end]) == "" || indentOf(lines[end]) <= indentOf(lines[start]) {
+			break
+		}
+
+		values = append(values, lines[end])
+	}
+
+	return dedent(values), end
+}
+
+// dedent removes the indentation that all of the lines have in common,
+// leaving a value on the line of its label as it is
+func dedent(lines []string) []string {
+	common := -1
+
+	for _, line := range lines {
+		if indent := indentOf(line); indent != 0 && (common == -1 || indent < common) {
+			common = indent
+		}
+	}
+
+	var dedented []string
+	for _, line := range lines {
+		if indentOf(line) >= common && common != -1 {
+			line = line[common:]
+		}
+
+		dedented = append(dedented, line)
+	}
+
+	return dedented
+}
+
+// diffLineText drops the marker and the space after it from a line of a diff
+func diffLineText(line string) string {
+	if len(line) < 2 {
+		return ""
+	}
+
+	return line[2:]
+}
+
+// SideBySide lines up the two values, pairing up lines that changed
+// and marking the characters that differ between each pair
+func (c Comparison) SideBySide() []DiffRow {
+	var (
+		rows             []DiffRow
+		removed, added   []string
+		flushReplacement = func() {
+			for i := 0; i < len(removed) || i < len(added); i++ {
+				var row DiffRow
+
+				switch {
+				case i >= len(removed):
+					row.Actual = []DiffSpan{{Text: added[i], Changed: true}}
+				case i >= len(added):
+					row.Expected = []DiffSpan{{Text: removed[i], Changed: true}}
+				default:
+					row.Expected, row.Actual = diffSpans(removed[i], added[i])
+				}
+
+				rows = append(rows, row)
+			}
+
+			removed, added = nil, nil
+		}
+	)
+
+	for _, op := range diffOps(c.Expected, c.Actual) {
+		switch op.kind {
+		case '-':
+			removed = append(removed, op.text)
+		case '+':
+			added = append(added, op.text)
+		default:
+			flushReplacement()
+			rows = append(rows, DiffRow{
+				Expected: []DiffSpan{{Text: op.text}},
+				Actual:   []DiffSpan{{Text: op.text}},
+			})
+		}
+	}
+
+	flushReplacement()
+	return rows
+}
+
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// diffOps is the shortest edit from one set of lines to the other,
+// by way of their longest common subsequence
+func diffOps(a, b []string) []diffOp {
+	if len(a)*len(b) > maxDiffCells {
+		var ops []diffOp
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			case lengths[i+1][j] >= lengths[i][j+1]:
+				lengths[i][j] = lengths[i+1][j]
+			default:
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	var (
+		ops  []diffOp
+		i, j int
+	)
+
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i, j = i+1, j+1
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+
+	return ops
+}
+
+// diffSpans marks the characters of each line that aren't
+// part of what the two lines have in common
+func diffSpans(a, b string) ([]DiffSpan, []DiffSpan) {
+	var (
+		ar = []rune(a)
+		br = []rune(b)
+	)
+
+	if len(ar)*len(br) > maxDiffCells {
+		// only what the lines start and end with is kept
+		prefix := 0
+		for prefix < len(ar) && prefix < len(br) && ar[prefix] == br[prefix] {
+			prefix++
+		}
+
+		suffix := 0
+		for suffix < len(ar)-prefix && suffix < len(br)-prefix && ar[len(ar)-1-suffix] == br[len(br)-1-suffix] {
+			suffix++
+		}
+
+		return affixSpans(ar, prefix, suffix), affixSpans(br, prefix, suffix)
+	}
+
+	var as, bs []DiffSpan
+
+	for _, op := range diffOps(diffChars(a), diffChars(b)) {
+		switch op.kind {
+		case '-':
+			as = appendSpan(as, op.text, true)
+		case '+':
+			bs = appendSpan(bs, op.text, true)
+		default:
+			as = appendSpan(as, op.text, false)
+			bs = appendSpan(bs, op.text, false)
+		}
+	}
+
+	return as, bs
+}
+
+// diffChars splits a line into its characters, so that only
+// the part of a word that changed is highlighted
+func diffChars(line string) []string {
+	var chars []string
+
+	for _, r := range line {
+		chars = append(chars, string(r))
+	}
+
+	return chars
+}
+
+func affixSpans(r []rune, prefix int, suffix int) []DiffSpan {
+	var spans []DiffSpan
+
+	spans = appendSpan(spans, string(r[:prefix]), false)
+	spans = appendSpan(spans, string(r[prefix:len(r)-suffix]), true)
+	spans = appendSpan(spans, string(r[len(r)-suffix:]), false)
+
+	return spans
+}
+
+// appendSpan adds text to the last span when it changed as much as
+// the text did, so that runs of changed characters stay together
+func appendSpan(spans []DiffSpan, text string, changed bool) []DiffSpan {
+	if text == "" {
+		return spans
+	}
+
+	if len(spans) != 0 && spans[len(spans)-1].Changed == changed {
+		spans[len(spans)-1].Text = spans[len(spans)-1].Text + text
+		return spans
+	}
+
+	return append(spans, DiffSpan{Text: text, Changed: changed})
+}
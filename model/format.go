@@ -0,0 +1,195 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// sniffLineCount is how many lines of input are looked at
+// for a format to claim them before giving up on detecting one
+const sniffLineCount = 20
+
+// maxLineLength is the longest line of output that can be read,
+// which go test's json for a panic or a large output can come close to
+const maxLineLength = 64 * 1024 * 1024
+
+// TestParser reads the output of a test runner into the model
+type TestParser interface {
+	// ParseStep parses the lines of a step from a CI log
+	ParseStep(id *int, step *Step)
+
+	// ParseStdin parses output as it is written, sending
+	// everything that it is done with on the parser's channels,
+	// and returns why stdin couldn't be read to its end, if it couldn't
+	ParseStdin(stdin io.Reader) error
+}
+
+// ScanLines calls fn with each line of r. When r can't be read any
+// further, the rest of it is thrown away, so that whatever writes to it
+// isn't left blocked, and the reason is returned.
+func ScanLines(r io.Reader, fn func(line string)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineLength)
+
+	for scanner.Scan() {
+		fn(scanner.Text())
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		io.Copy(ioutil.Discard, r)
+	}
+
+	return err
+}
+
+// ParserChans are where a TestParser sends what it parsed.
+// Any that aren't needed can be left nil.
+type ParserChans struct {
+	TestSuites    chan TestSuite
+	BuildFailures chan BuildFailure
+	Packages      chan Package
+	Benchmarks    chan Benchmark
+	Lines         chan string
+	Done          chan bool
+}
+
+// Format is a kind of test output that go-swt understands
+type Format struct {
+	// Name is how the format is chosen with --format
+	Name string
+
+	// Detect reports whether the first lines of the input are in the format
+	Detect func(lines []string) bool
+
+	NewParser func(chans ParserChans) TestParser
+}
+
+// formats are tried in order when detecting one,
+// so more particular formats come first
+var formats = []Format{
+	{
+		Name:   "ginkgo-report",
+		Detect: detectGinkgoReport,
+		NewParser: func(chans ParserChans) TestParser {
+			return NewGinkgoReportParser(chans)
+		},
+	},
+	{
+		Name:   "junit",
+		Detect: detectJUnit,
+		NewParser: func(chans ParserChans) TestParser {
+			return NewJUnitParser(chans)
+		},
+	},
+	{
+		Name:   "ginkgo",
+		Detect: detectGinkgo,
+		NewParser: func(chans ParserChans) TestParser {
+			return NewGinkgoParser(chans)
+		},
+	},
+	{
+		Name:   "go",
+		Detect: detectGoTest,
+		NewParser: func(chans ParserChans) TestParser {
+			return NewParser(chans.TestSuites, chans.BuildFailures, chans.Packages, chans.Benchmarks, chans.Lines, chans.Done)
+		},
+	},
+}
+
+// RegisterFormat adds a format, which is tried before the
+// built-in ones, so that its detection can be more lenient
+func RegisterFormat(format Format) {
+	formats = append([]Format{format}, formats...)
+}
+
+// LookupFormat finds the format with the given name
+func LookupFormat(name string) (Format, bool) {
+	for _, format := range formats {
+		if format.Name == name {
+			return format, true
+		}
+	}
+
+	return Format{}, false
+}
+
+// FormatNames are the names of every format, for choosing one by
+func FormatNames() []string {
+	var names []string
+
+	for _, format := range formats {
+		names = append(names, format.Name)
+	}
+	return names
+}
+
+// DetectFormat finds the first format that claims the given lines,
+// falling back to plain go test output if none of them does
+func DetectFormat(lines []string) Format {
+	format, ok := detectFormat(lines)
+	if !ok {
+		format, _ = LookupFormat("go")
+	}
+
+	return format
+}
+
+// SniffFormat reads from stdin until a format claims the lines read so far,
+// or until there has been enough to give up on it. It returns the format
+// along with a reader that starts over from the beginning of stdin.
+func SniffFormat(stdin io.Reader) (Format, io.Reader) {
+	var (
+		lines    []string
+		buffered bytes.Buffer
+		reader   = bufio.NewReader(stdin)
+	)
+
+	for len(lines) < sniffLineCount {
+		line, err := reader.ReadString('\n')
+		buffered.WriteString(line)
+
+		if line != "" {
+			lines = append(lines, strings.TrimRight(line, "\r\n"))
+		}
+
+		if _, ok := detectFormat(lines); ok || err != nil {
+			break
+		}
+	}
+
+	return DetectFormat(lines), io.MultiReader(&buffered, reader)
+}
+
+func detectFormat(lines []string) (Format, bool) {
+	if len(lines) == 0 {
+		return Format{}, false
+	}
+
+	for _, format := range formats {
+		if format.Detect(lines) {
+			return format, true
+		}
+	}
+
+	return Format{}, false
+}
+
+var goTestMatcher = regexp.MustCompile(`^(=== (RUN|PAUSE|CONT|NAME)|--- (PASS|FAIL|SKIP|BENCH):|(ok|FAIL|\?)\s+\S+|PASS$|FAIL$|# \S+|goos: |Suite: |\{"(Time|Action|ImportPath)":)`)
+
+// detectGoTest claims anything that looks like go test wrote it,
+// in verbose or json output
+func detectGoTest(lines []string) bool {
+	for _, line := range lines {
+		if goTestMatcher.MatchString(line) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,529 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ginkgoPhase is which part of a spec's output is being read
+type ginkgoPhase int
+
+const (
+	// ginkgoHeader is the containers and text of the spec, up to its location
+	ginkgoHeader ginkgoPhase = iota
+	ginkgoOutput
+
+	// ginkgoBlock is the hierarchy printed beneath a result,
+	// up until the blank line before the failure
+	ginkgoBlock
+
+	// ginkgoSummary repeats every failure, after all the specs ran
+	ginkgoSummary
+)
+
+// ginkgoResult is a spec's result line, which comes
+// before its hierarchy when only failures are printed
+type ginkgoResult struct {
+	status   TestStatus
+	panicked bool
+	elapsed  time.Duration
+}
+
+// GinkgoParser reads Ginkgo v1 and v2 verbose output, whether the
+// ginkgo command line printed it or go test did, running a suite
+type GinkgoParser struct {
+	*Parser
+
+	ginkgoSuiteMatcher     *regexp.Regexp
+	ginkgoSeparatorMatcher *regexp.Regexp
+	ginkgoLocationMatcher  *regexp.Regexp
+	ginkgoResultMatcher    *regexp.Regexp
+	ginkgoSummaryMatcher   *regexp.Regexp
+	ginkgoRanMatcher       *regexp.Regexp
+	ginkgoTallyMatcher     *regexp.Regexp
+	ginkgoLabelMatcher     *regexp.Regexp
+	ginkgoNodeMatcher      *regexp.Regexp
+
+	// ginkgo prints each spec between lines of dashes, with its
+	// containers before its output and its result after it
+	ginkgoSuite  string
+	ginkgoSpec   string
+	ginkgoPhase  ginkgoPhase
+	ginkgoHeader []string
+	ginkgoBlock  []string
+	ginkgoResult ginkgoResult
+}
+
+func NewGinkgoParser(chans ParserChans) *GinkgoParser {
+	g := &GinkgoParser{
+		Parser: NewParser(chans.TestSuites, chans.BuildFailures, chans.Packages, chans.Benchmarks, chans.Lines, chans.Done),
+
+		ginkgoSuiteMatcher:     regexp.MustCompile(`^Running Suite: (.+?)(?: - \S+)?$`),
+		ginkgoSeparatorMatcher: regexp.MustCompile(`^-{30}$`),
+		ginkgoLocationMatcher:  regexp.MustCompile(`^\S+\.go:\d+$`),
+		ginkgoResultMatcher:    regexp.MustCompile(`^(•!?|S|P)(?: \[?(FAILED|PANICKED|SKIPPED|PENDING|TIMEDOUT|INTERRUPTED|ABORTED|Failure|Panic|Timeout|SKIPPING)\]?)?(?: in [^\[]+)?(?: \[(?:SLOW TEST:)?([\d.]+) seconds\])?$`),
+		ginkgoSummaryMatcher:   regexp.MustCompile(`^Summarizing \d+ Failures?:$`),
+		ginkgoRanMatcher:       regexp.MustCompile(`^Ran \d+ of (\d+) Specs? in ([\d.]+) seconds$`),
+		ginkgoTallyMatcher:     regexp.MustCompile(`^(?:SUCCESS!|FAIL!) -- (\d+) Passed \| (\d+) Failed \| (?:\d+ Flaked \| )?(\d+) Pending \| (\d+) Skipped`),
+		ginkgoLabelMatcher:     regexp.MustCompile(`^\[[^\]]*\]$`),
+		ginkgoNodeMatcher:      regexp.MustCompile(`^\[[A-Za-z]+\] | \[[A-Za-z]+\]$`),
+	}
+
+	g.output = g
+	return g
+}
+
+// finishPackage forgets the suite when go test is done
+// with its package, even if the suite never finished
+func (g *GinkgoParser) finishPackage() {
+	g.ginkgoSuite = ""
+	g.ginkgoSpec = ""
+}
+
+// parseOutput handles everything between a Ginkgo suite's
+// "Running Suite:" banner and its tally, turning each container
+// of a spec into a run that the spec is nested under
+func (g *GinkgoParser) parseOutput(id *int, step *Step, line string) bool {
+	text := strings.TrimSpace(ansiMatcher.ReplaceAllString(line, ""))
+
+	if g.ginkgoSuite == "" {
+		suiteMatches := g.ginkgoSuiteMatcher.FindStringSubmatch(text)
+		if len(suiteMatches) != 2 {
+			return false
+		}
+
+		g.startGinkgoSuite(id, step, suiteMatches[1])
+		return true
+	}
+
+	tallyMatches := g.ginkgoTallyMatcher.FindStringSubmatch(text)
+	if len(tallyMatches) == 5 {
+		var (
+			passed, _  = strconv.Atoi(tallyMatches[1])
+			failed, _  = strconv.Atoi(tallyMatches[2])
+			pending, _ = strconv.Atoi(tallyMatches[3])
+			skipped, _ = strconv.Atoi(tallyMatches[4])
+		)
+
+		g.finishGinkgoSpec(id, step)
+		g.finishGinkgoSuite(step, passed, failed, pending+skipped)
+		return true
+	}
+
+	ranMatches := g.ginkgoRanMatcher.FindStringSubmatch(text)
+	if len(ranMatches) == 3 {
+		var (
+			suite      = &step.TestSuites[g.suiteIndexMapping[g.ginkgoSuite]]
+			total, _   = strconv.Atoi(ranMatches[1])
+			elapsed, _ = strconv.ParseFloat(ranMatches[2], 64)
+		)
+
+		suite.TestCount = total
+		suite.Elapsed = time.Duration(elapsed * float64(time.Second))
+		return true
+	}
+
+	if g.ginkgoSummaryMatcher.MatchString(text) {
+		g.finishGinkgoSpec(id, step)
+		g.ginkgoPhase = ginkgoSummary
+		return true
+	}
+
+	if g.ginkgoSeparatorMatcher.MatchString(text) {
+		g.finishGinkgoSpec(id, step)
+		return true
+	}
+
+	resultMatches := g.ginkgoResultMatcher.FindStringSubmatch(text)
+	if len(resultMatches) == 4 && g.ginkgoPhase != ginkgoSummary {
+		g.startGinkgoResult(step, resultMatches[1], resultMatches[2], resultMatches[3])
+		return true
+	}
+
+	switch g.ginkgoPhase {
+	case ginkgoHeader:
+		switch {
+		case text == "":
+			g.ginkgoHeader = nil
+		case g.ginkgoLocationMatcher.MatchString(text) && len(g.ginkgoHeader) != 0:
+			g.startGinkgoSpec(id, step, g.ginkgoParts(g.ginkgoHeader))
+			g.ginkgoPhase = ginkgoOutput
+		default:
+			g.ginkgoHeader = append(g.ginkgoHeader, line)
+		}
+	case ginkgoBlock:
+		if text != "" {
+			g.ginkgoBlock = append(g.ginkgoBlock, line)
+			break
+		}
+
+		g.finishGinkgoBlock(id, step)
+		g.ginkgoPhase = ginkgoOutput
+	case ginkgoOutput:
+		for _, run := range g.testRuns(step, g.ginkgoSpec) {
+			// throw away blank first lines
+			if text == "" && len(run.Lines) == 0 {
+				continue
+			}
+
+			run.Lines = append(run.Lines, line)
+		}
+	}
+
+	return true
+}
+
+// startGinkgoSuite starts a suite for the specs of a "Running Suite:" banner,
+// nested under the go test that ran them. The ginkgo command line doesn't
+// print go test's own output, so without one the suite stands on its own.
+func (g *GinkgoParser) startGinkgoSuite(id *int, step *Step, description string) {
+	if g.mainTestRunName == "" || g.mainTestHasSuite {
+		g.tallyTestSuites(step)
+		g.sendTestSuites(step)
+
+		g.mainTestRunName = description
+		g.mainTestLines = nil
+		g.mainTestHasSuite = false
+	}
+
+	g.startTestSuite(id, step, description)
+	step.TestSuites[len(step.TestSuites)-1].Name = g.mainTestRunName
+
+	g.currentTestSuite = description
+	g.currentTestRun = g.mainTestRunName
+	g.ginkgoSuite = description
+	g.ginkgoSpec = ""
+	g.ginkgoPhase = ginkgoHeader
+	g.ginkgoHeader = nil
+}
+
+// startGinkgoSpec adds a run for the spec and one for each of its containers
+func (g *GinkgoParser) startGinkgoSpec(id *int, step *Step, parts []string) {
+	if len(parts) == 0 {
+		return
+	}
+
+	suite := &step.TestSuites[g.suiteIndexMapping[g.ginkgoSuite]]
+
+	g.ginkgoSpec = g.addNestedTestRun(id, suite, g.ginkgoSuite, parts)
+	g.currentTestRun = g.ginkgoSpec
+}
+
+// startGinkgoResult records how the spec went. When only failures are
+// printed, there was no header, so the hierarchy beneath is waited for.
+func (g *GinkgoParser) startGinkgoResult(step *Step, marker string, state string, seconds string) {
+	elapsed, _ := strconv.ParseFloat(seconds, 64)
+
+	g.ginkgoResult = ginkgoResult{
+		status:   TestPassed,
+		panicked: strings.HasSuffix(marker, "!"),
+		elapsed:  time.Duration(elapsed * float64(time.Second)),
+	}
+
+	switch {
+	case state == "PANICKED" || state == "Panic":
+		g.ginkgoResult.status = TestFailed
+		g.ginkgoResult.panicked = true
+	case state == "TIMEDOUT" || state == "Timeout":
+		g.ginkgoResult.status = TestTimedOut
+	case state != "" && state != "SKIPPED" && state != "SKIPPING" && state != "PENDING":
+		g.ginkgoResult.status = TestFailed
+	case marker == "S" || marker == "P":
+		g.ginkgoResult.status = TestSkipped
+	}
+
+	g.ginkgoPhase = ginkgoBlock
+	g.ginkgoBlock = nil
+
+	g.recordGinkgoResult(step)
+}
+
+func (g *GinkgoParser) recordGinkgoResult(step *Step) {
+	if g.ginkgoSpec == "" {
+		return
+	}
+
+	for _, run := range g.testRuns(step, g.ginkgoSpec) {
+		run.Status = g.ginkgoResult.status
+		run.Panicked = g.ginkgoResult.panicked
+		run.Elapsed = g.ginkgoResult.elapsed
+	}
+
+	if g.ginkgoResult.status.failed() {
+		g.lastFailedTestRun = g.ginkgoSpec
+	}
+}
+
+// finishGinkgoBlock starts the spec from the hierarchy beneath its
+// result, if it didn't have a header. Ginkgo v1 prints a line and a
+// location for each container, while v2 prints them on a single line.
+func (g *GinkgoParser) finishGinkgoBlock(id *int, step *Step) {
+	if g.ginkgoSpec != "" {
+		return
+	}
+
+	var hierarchy []string
+	for _, line := range g.ginkgoBlock {
+		if !g.ginkgoLocationMatcher.MatchString(strings.TrimSpace(ansiMatcher.ReplaceAllString(line, ""))) {
+			hierarchy = append(hierarchy, line)
+		}
+	}
+
+	g.startGinkgoSpec(id, step, g.ginkgoParts(hierarchy))
+	g.recordGinkgoResult(step)
+}
+
+// finishGinkgoSpec is called at the line of dashes after a spec
+func (g *GinkgoParser) finishGinkgoSpec(id *int, step *Step) {
+	if g.ginkgoPhase == ginkgoBlock {
+		g.finishGinkgoBlock(id, step)
+	}
+
+	g.ginkgoSpec = ""
+	g.ginkgoPhase = ginkgoHeader
+	g.ginkgoHeader = nil
+	g.ginkgoBlock = nil
+	g.currentTestRun = g.mainTestRunName
+}
+
+// finishGinkgoSuite gives the suite the same tally that spec prints
+func (g *GinkgoParser) finishGinkgoSuite(step *Step, passed int, failed int, skipped int) {
+	si := g.suiteIndexMapping[g.ginkgoSuite]
+	step.TestSuites[si].Title = step.TestSuites[si].Title + fmt.Sprintf(" (Passed: %d | Failed: %d | Skipped: %d)", passed, failed, skipped)
+
+	// go test reports on the test that ran the suite afterwards,
+	// which the ginkgo command line leaves out
+	if failed == 0 {
+		g.setTestRunStatus(step, g.mainTestRunName, TestPassed)
+	} else {
+		g.setTestRunStatus(step, g.mainTestRunName, TestFailed)
+	}
+
+	g.ginkgoSuite = ""
+	g.currentTestRun = g.mainTestRunName
+}
+
+// ginkgoParts splits the hierarchy of a spec into the text of each
+// container and of the spec itself. Ginkgo colors each container
+// differently, so without colors the containers on a single line
+// can't be told apart, and only the spec is split from them.
+func (g *GinkgoParser) ginkgoParts(lines []string) []string {
+	var parts []string
+
+	for _, line := range lines {
+		for _, segment := range ansiMatcher.Split(line, -1) {
+			segment = strings.TrimSpace(segment)
+			if segment == "" || g.ginkgoLabelMatcher.MatchString(segment) {
+				continue
+			}
+
+			if i := strings.Index(segment, " [It] "); i != -1 {
+				parts = append(parts, segment[:i])
+				segment = segment[i+1:]
+			}
+
+			parts = append(parts, g.ginkgoNodeMatcher.ReplaceAllString(segment, ""))
+		}
+	}
+
+	return parts
+}
+
+// GinkgoReportParser reads the file that Ginkgo writes with --json-report
+type GinkgoReportParser struct {
+	*GinkgoParser
+}
+
+func NewGinkgoReportParser(chans ParserChans) *GinkgoReportParser {
+	return &GinkgoReportParser{
+		GinkgoParser: NewGinkgoParser(chans),
+	}
+}
+
+type ginkgoReport struct {
+	SuiteDescription string
+	RunTime          time.Duration
+	PreRunStats      struct {
+		TotalSpecs int
+	}
+	SpecReports []ginkgoSpecReport
+}
+
+type ginkgoSpecReport struct {
+	ContainerHierarchyTexts []string
+	LeafNodeType            string
+	LeafNodeText            string
+	State                   string
+	RunTime                 time.Duration
+
+	CapturedGinkgoWriterOutput string
+	CapturedStdOutErr          string
+
+	Failure *struct {
+		Message         string
+		ForwardedPanic  string
+		FailureNodeType string
+		Location        struct {
+			FileName       string
+			LineNumber     int
+			FullStackTrace string
+		}
+	}
+}
+
+func (g *GinkgoReportParser) ParseStep(id *int, step *Step) {
+	var reports []ginkgoReport
+
+	// a report that can't be read has no specs to show
+	_ = json.Unmarshal([]byte(strings.Join(step.Lines, "\n")), &reports)
+
+	for _, report := range reports {
+		g.startGinkgoSuite(id, step, report.SuiteDescription)
+
+		var passed, failed, skipped int
+
+		for _, spec := range report.SpecReports {
+			status := ginkgoStatus(spec.State)
+
+			// suite setup only matters when it fails
+			if spec.LeafNodeType != "It" && !status.failed() {
+				continue
+			}
+
+			text := spec.LeafNodeText
+			if text == "" {
+				text = "[" + spec.LeafNodeType + "]"
+			}
+
+			g.startGinkgoSpec(id, step, append(append([]string{}, spec.ContainerHierarchyTexts...), text))
+			g.ginkgoResult = ginkgoResult{
+				status:   status,
+				panicked: spec.State == "panicked",
+				elapsed:  spec.RunTime,
+			}
+			g.recordGinkgoResult(step)
+
+			for _, run := range g.testRuns(step, g.ginkgoSpec) {
+				run.Lines = ginkgoSpecLines(spec)
+			}
+
+			switch {
+			case status.failed():
+				failed = failed + 1
+			case status == TestSkipped:
+				skipped = skipped + 1
+			default:
+				passed = passed + 1
+			}
+		}
+
+		g.finishGinkgoSpec(id, step)
+		g.finishGinkgoSuite(step, passed, failed, skipped)
+
+		suite := &step.TestSuites[g.suiteIndexMapping[report.SuiteDescription]]
+		suite.TestCount = report.PreRunStats.TotalSpecs
+		suite.Elapsed = report.RunTime
+	}
+}
+
+func (g *GinkgoReportParser) ParseStdin(stdin io.Reader) error {
+	var (
+		id   = 1
+		step = Step{}
+	)
+
+	err := ScanLines(stdin, func(line string) {
+		step.Lines = append(step.Lines, line)
+		g.sendLine(line)
+	})
+
+	g.ParseStep(&id, &step)
+
+	if g.doneChan != nil {
+		g.sendTestSuites(&step)
+
+		time.Sleep(time.Millisecond)
+		g.doneChan <- true
+	}
+
+	return err
+}
+
+func ginkgoStatus(state string) TestStatus {
+	switch state {
+	case "passed":
+		return TestPassed
+	case "skipped", "pending":
+		return TestSkipped
+	case "timedout":
+		return TestTimedOut
+	default:
+		return TestFailed
+	}
+}
+
+// ginkgoSpecLines lays out what a report has to say about
+// a spec the way Ginkgo prints it
+func ginkgoSpecLines(spec ginkgoSpecReport) []string {
+	var lines []string
+
+	for _, output := range []string{spec.CapturedStdOutErr, spec.CapturedGinkgoWriterOutput} {
+		if output != "" {
+			lines = append(lines, strings.Split(strings.TrimRight(output, "\n"), "\n")...)
+		}
+	}
+
+	failure := spec.Failure
+	if failure == nil {
+		return lines
+	}
+
+	var (
+		state    = strings.ToUpper(spec.State)
+		location = fmt.Sprintf("%s:%d", failure.Location.FileName, failure.Location.LineNumber)
+	)
+
+	lines = append(lines, strings.Split(fmt.Sprintf("[%s] %s", state, failure.Message), "\n")...)
+	lines = append(lines, fmt.Sprintf("In [%s] at: %s", failure.FailureNodeType, location))
+
+	if failure.ForwardedPanic != "" {
+		lines = append(lines, "", failure.ForwardedPanic, "", "Full Stack Trace")
+		lines = append(lines, strings.Split(failure.Location.FullStackTrace, "\n")...)
+	}
+
+	return lines
+}
+
+var (
+	ginkgoBannerMatcher = regexp.MustCompile(`^Running Suite: `)
+	ginkgoReportMatcher = regexp.MustCompile(`^\s*"SuitePath": `)
+)
+
+// detectGinkgo claims the output of a Ginkgo suite, which starts with its
+// banner, either right away or beneath go test's line for the test running it
+func detectGinkgo(lines []string) bool {
+	for _, line := range lines {
+		if ginkgoBannerMatcher.MatchString(strings.TrimSpace(ansiMatcher.ReplaceAllString(line, ""))) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// detectGinkgoReport claims the json that Ginkgo writes with --json-report,
+// whose first field is the path to the suite
+func detectGinkgoReport(lines []string) bool {
+	for _, line := range lines {
+		if ginkgoReportMatcher.MatchString(line) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+// goroutineStacks picks the stacks of the goroutines that were running
+// the code of the given test out of the dump that go prints for a SIGQUIT,
+// or all of them when none were, leaving out the registers after them
+func goroutineStacks(dump []string, testName string) []string {
+	var (
+		stacks [][]string
+		stack  []string
+
+		goroutineRegex = regexp.MustCompile(`^goroutine \d+ .*\[.+\]:$`)
+		addressRegex   = regexp.MustCompile(` fp=0x[0-9a-f]+ sp=0x[0-9a-f]+ pc=0x[0-9a-f]+$`)
+		testFrameRegex = regexp.MustCompile(`^\S+\.` + regexp.QuoteMeta(testName) + `(\(|\.func)`)
+	)
+
+	for _, line := range dump {
+		switch {
+		case goroutineRegex.MatchString(line):
+			stack = []string{line}
+		case stack == nil:
+			continue
+		case strings.TrimSpace(line) == "":
+			stacks = append(stacks, stack)
+			stack = nil
+		default:
+			stack = append(stack, addressRegex.ReplaceAllString(line, ""))
+		}
+	}
+
+	if stack != nil {
+		stacks = append(stacks, stack)
+	}
+
+	var testStacks [][]string
+	for _, stack := range stacks {
+		for _, line := range stack {
+			if testName != "" && testFrameRegex.MatchString(line) {
+				testStacks = append(testStacks, stack)
+				break
+			}
+		}
+	}
+
+	if len(testStacks) == 0 {
+		testStacks = stacks
+	}
+
+	var lines []string
+	if len(dump) != 0 {
+		lines = append(lines, dump[0])
+	}
+
+	for _, stack := range testStacks {
+		lines = append(lines, "")
+		lines = append(lines, stack...)
+	}
+
+	return lines
+}
@@ -0,0 +1,471 @@
+package model
+
+import (
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// JUnitParser reads a JUnit XML report, as written by go-junit-report
+// or by the test runners of other languages
+type JUnitParser struct {
+	*Parser
+}
+
+func NewJUnitParser(chans ParserChans) *JUnitParser {
+	return &JUnitParser{
+		Parser: NewParser(chans.TestSuites, chans.BuildFailures, chans.Packages, chans.Benchmarks, chans.Lines, chans.Done),
+	}
+}
+
+type junitTestSuites struct {
+	XMLName      xml.Name         `xml:"testsuites"`
+	TestCount    int              `xml:"tests,attr"`
+	FailureCount int              `xml:"failures,attr"`
+	ErrorCount   int              `xml:"errors,attr"`
+	SkipCount    int              `xml:"skipped,attr"`
+	TestSuites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	XMLName      xml.Name         `xml:"testsuite"`
+	Name         string           `xml:"name,attr"`
+	TestCount    int              `xml:"tests,attr"`
+	FailureCount int              `xml:"failures,attr"`
+	ErrorCount   int              `xml:"errors,attr"`
+	SkipCount    int              `xml:"skipped,attr"`
+	Time         string           `xml:"time,attr"`
+	TestSuites   []junitTestSuite `xml:"testsuite"`
+	TestCases    []junitTestCase  `xml:"testcase"`
+
+	elapsed time.Duration
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failures  []junitResult `xml:"failure"`
+	Errors    []junitResult `xml:"error"`
+	Skipped   *junitResult  `xml:"skipped"`
+	SystemOut *junitOutput  `xml:"system-out"`
+	SystemErr *junitOutput  `xml:"system-err"`
+}
+
+type junitResult struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",cdata"`
+}
+
+type junitOutput struct {
+	Text string `xml:",cdata"`
+}
+
+// ParseStep turns each <testsuite> into a package, with a suite for each
+// test like go test's output gets. A test nests under its classname when
+// that is different from the name of its <testsuite>, and go subtests
+// nest by the "/" separated parts of their name.
+func (j *JUnitParser) ParseStep(id *int, step *Step) {
+	var suites []junitTestSuite
+
+	// a report that can't be read has no tests to show
+	decoder := xml.NewDecoder(strings.NewReader(strings.Join(step.Lines, "\n")))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "testsuites":
+			continue
+		case "testsuite":
+			var suite junitTestSuite
+			if decoder.DecodeElement(&suite, &start) == nil {
+				suites = append(suites, suite)
+			}
+		default:
+			_ = decoder.Skip()
+		}
+	}
+
+	for _, suite := range suites {
+		j.parseJUnitSuite(id, step, suite)
+	}
+}
+
+func (j *JUnitParser) ParseStdin(stdin io.Reader) error {
+	var (
+		id   = 1
+		step = Step{}
+	)
+
+	err := ScanLines(stdin, func(line string) {
+		step.Lines = append(step.Lines, line)
+		j.sendLine(line)
+	})
+
+	j.ParseStep(&id, &step)
+
+	if j.doneChan != nil {
+		j.sendTestSuites(&step)
+
+		time.Sleep(time.Millisecond)
+		j.doneChan <- true
+	}
+
+	return err
+}
+
+func (j *JUnitParser) parseJUnitSuite(id *int, step *Step, suite junitTestSuite) {
+	// some runners nest a suite for each class within one for the file
+	for _, nested := range suite.TestSuites {
+		j.parseJUnitSuite(id, step, nested)
+	}
+
+	if len(suite.TestCases) == 0 {
+		return
+	}
+
+	success := true
+
+	for _, testCase := range suite.TestCases {
+		var (
+			name = j.startJUnitTestCase(id, step, suite.Name, testCase)
+			runs = j.testRuns(step, name)
+		)
+
+		status := junitStatus(testCase)
+		if status.failed() {
+			success = false
+		}
+
+		for _, run := range runs {
+			run.Status = status
+			run.Elapsed = junitTime(testCase.Time)
+			run.Lines = junitTestCaseLines(testCase)
+			run.Panicked = status.failed() && junitPanicked(run.Lines)
+			run.Assertions = parseAssertions(run.Lines)
+		}
+	}
+
+	// placeholders for parts of a name that have no test case of their own
+	// pass, and fail along with the tests nested under them
+	for _, name := range j.untalliedTestSuites {
+		ts := &step.TestSuites[j.suiteIndexMapping[name]]
+
+		for _, run := range ts.AllTestRuns() {
+			if run.Status == TestRunning {
+				j.setTestRunStatus(step, run.Name, TestPassed)
+			}
+		}
+
+		ts.Elapsed = ts.TestRuns[0].Elapsed
+	}
+
+	j.tallyTestSuites(step)
+
+	pkg := Package{
+		ID:      *id,
+		Name:    suite.Name,
+		Success: success,
+		Elapsed: junitTime(suite.Time),
+	}
+
+	*id = *id + 1
+
+	step.AddPackage(pkg)
+
+	j.sendTestSuites(step)
+	j.sendPackage(pkg)
+
+	// test names are only unique within a package
+	j.suiteIndexMapping = map[string]int{}
+	j.runIndexMapping = map[string]map[string][]int{}
+	j.runSuiteMapping = map[string][]string{}
+}
+
+// startJUnitTestCase adds a run for the test case, in a suite named after
+// the first part of its name, and returns the run's full name
+func (j *JUnitParser) startJUnitTestCase(id *int, step *Step, suiteName string, testCase junitTestCase) string {
+	parts := strings.Split(testCase.Name, "/")
+	if testCase.Classname != "" && testCase.Classname != suiteName {
+		parts = append([]string{testCase.Classname}, parts...)
+	}
+
+	if _, ok := j.suiteIndexMapping[parts[0]]; !ok {
+		j.mainTestRunName = parts[0]
+		j.mainTestLines = nil
+		j.startPlainTestSuite(id, step)
+	}
+
+	if len(parts) == 1 {
+		return parts[0]
+	}
+
+	suite := &step.TestSuites[j.suiteIndexMapping[parts[0]]]
+
+	name := strings.Join(parts, "/")
+	if _, ok := j.runIndexMapping[parts[0]][name]; ok {
+		// the placeholder made for a test case that came after its subtests
+		return name
+	}
+
+	return j.addNestedTestRun(id, suite, parts[0], parts[1:])
+}
+
+func junitStatus(testCase junitTestCase) TestStatus {
+	switch {
+	case len(testCase.Failures) != 0 || len(testCase.Errors) != 0:
+		return TestFailed
+	case testCase.Skipped != nil:
+		return TestSkipped
+	default:
+		return TestPassed
+	}
+}
+
+// junitTime reads the seconds that a report gives, which
+// some runners write with a comma between the thousands
+func junitTime(seconds string) time.Duration {
+	elapsed, _ := strconv.ParseFloat(strings.ReplaceAll(seconds, ",", ""), 64)
+	return time.Duration(elapsed * float64(time.Second))
+}
+
+// junitTestCaseLines lays out the output of a test case, with the message
+// of a failure or skip standing in for its text when it has none
+func junitTestCaseLines(testCase junitTestCase) []string {
+	var (
+		lines   []string
+		results = append(append([]junitResult{}, testCase.Failures...), testCase.Errors...)
+	)
+
+	if testCase.Skipped != nil {
+		results = append(results, *testCase.Skipped)
+	}
+
+	for _, result := range results {
+		text := result.Text
+		if strings.TrimSpace(text) == "" {
+			text = result.Message
+		}
+
+		lines = append(lines, junitLines(text)...)
+	}
+
+	for _, output := range []*junitOutput{testCase.SystemOut, testCase.SystemErr} {
+		if output != nil {
+			lines = append(lines, junitLines(output.Text)...)
+		}
+	}
+
+	return lines
+}
+
+// junitPanicked reports whether the output has the panic that go
+// test prints when a test crashes
+func junitPanicked(lines []string) bool {
+	for _, line := range lines {
+		if strings.HasPrefix(line, "panic: ") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func junitLines(text string) []string {
+	text = strings.Trim(text, "\r\n")
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	return strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+}
+
+// WriteJUnit writes a JUnit XML report of the logs, with a <testsuite> for each
+// package and a <testcase> for each run, named the way go-junit-report names them
+func (l Logs) WriteJUnit(w io.Writer) error {
+	var report junitTestSuites
+
+	for i := range l {
+		report.TestSuites = append(report.TestSuites, l[i].junitTestSuites()...)
+	}
+
+	for _, suite := range report.TestSuites {
+		report.TestCount = report.TestCount + suite.TestCount
+		report.FailureCount = report.FailureCount + suite.FailureCount
+		report.ErrorCount = report.ErrorCount + suite.ErrorCount
+		report.SkipCount = report.SkipCount + suite.SkipCount
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "\t")
+
+	err = encoder.Encode(report)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// junitTestSuites groups the step's runs by package. Suites from
+// a package that go test hasn't reported on go by their own name.
+func (s *Step) junitTestSuites() []junitTestSuite {
+	var (
+		suites  []junitTestSuite
+		indexes = map[string]int{}
+		seen    = map[string]bool{}
+	)
+
+	suiteNamed := func(name string) *junitTestSuite {
+		i, ok := indexes[name]
+		if !ok {
+			suites = append(suites, junitTestSuite{Name: name})
+			i = len(suites) - 1
+			indexes[name] = i
+		}
+
+		return &suites[i]
+	}
+
+	for _, pkg := range s.Packages {
+		suiteNamed(pkg.Name).elapsed = pkg.Elapsed
+	}
+
+	for _, bf := range s.BuildFailures {
+		suite := suiteNamed(bf.Package)
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      "[build failed]",
+			Classname: bf.Package,
+			Time:      junitSeconds(0),
+			Failures:  []junitResult{{Message: "Build error", Text: junitText(bf.Lines)}},
+		})
+	}
+
+	for _, ts := range s.TestSuites {
+		name := ts.Package
+		if name == "" {
+			name = ts.Name
+		}
+
+		suite := suiteNamed(name)
+		if !s.HasPackage(name) {
+			suite.elapsed = suite.elapsed + ts.Elapsed
+		}
+
+		for _, run := range ts.AllTestRuns() {
+			// the main run of a spec test is part of each of its suites
+			if seen[name+" "+run.Name] {
+				continue
+			}
+
+			// containers of nested runs have no result of their own
+			if run.Status == TestRunning && len(run.TestRuns) != 0 {
+				continue
+			}
+
+			seen[name+" "+run.Name] = true
+			suite.TestCases = append(suite.TestCases, run.junitTestCase(name))
+		}
+	}
+
+	for i := range suites {
+		suites[i].tally()
+	}
+
+	return suites
+}
+
+func (s *junitTestSuite) tally() {
+	s.TestCount = len(s.TestCases)
+	s.Time = junitSeconds(s.elapsed)
+
+	for _, testCase := range s.TestCases {
+		switch {
+		case len(testCase.Failures) != 0:
+			s.FailureCount = s.FailureCount + 1
+		case len(testCase.Errors) != 0:
+			s.ErrorCount = s.ErrorCount + 1
+		case testCase.Skipped != nil:
+			s.SkipCount = s.SkipCount + 1
+		}
+	}
+}
+
+func (r *TestRun) junitTestCase(classname string) junitTestCase {
+	var (
+		testCase = junitTestCase{Name: r.Name, Classname: classname, Time: junitSeconds(r.Elapsed)}
+		text     = junitText(r.Lines)
+	)
+
+	switch r.Status {
+	case TestFailed:
+		testCase.Failures = []junitResult{{Message: "Failed", Text: text}}
+	case TestTimedOut:
+		testCase.Failures = []junitResult{{Message: "Timed out", Text: text}}
+	case TestStopped:
+		testCase.Errors = []junitResult{{Message: "Stopped", Text: text}}
+	case TestSkipped:
+		testCase.Skipped = &junitResult{Message: "Skipped", Text: text}
+	case TestRunning:
+		// the run never finished, like when go test crashed
+		testCase.Errors = []junitResult{{Message: "No test result", Text: text}}
+	default:
+		if text != "" {
+			testCase.SystemOut = &junitOutput{Text: text}
+		}
+	}
+
+	return testCase
+}
+
+// junitText joins the lines of output without their colors, or any
+// other characters that can't be written in xml
+func junitText(lines []string) string {
+	text := ansiMatcher.ReplaceAllString(strings.Join(lines, "\n"), "")
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			return r
+		case r < 0x20, r >= 0xd800 && r < 0xe000, r == 0xfffe, r == 0xffff:
+			return unicode.ReplacementChar
+		default:
+			return r
+		}
+	}, text)
+}
+
+func junitSeconds(elapsed time.Duration) string {
+	return strconv.FormatFloat(elapsed.Seconds(), 'f', 3, 64)
+}
+
+var junitMatcher = regexp.MustCompile(`(^|>)\s*<testsuites?[\s>]`)
+
+// detectJUnit claims xml whose root is a <testsuites> or <testsuite>
+func detectJUnit(lines []string) bool {
+	for _, line := range lines {
+		if junitMatcher.MatchString(line) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -3,6 +3,8 @@ package model
 import (
 	"bufio"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -78,7 +80,7 @@ func LogsFromFile(logPath string) (Logs, error) {
 	}
 
 	for i := range logs {
-		NewParser(nil, nil, nil).ParseGoTestStep(&id, &logs[i])
+		DetectFormat(logs[i].Lines).NewParser(ParserChans{}).ParseStep(&id, &logs[i])
 	}
 
 	return logs, nil
@@ -89,13 +91,8 @@ func (l Logs) toggleTestRuns(id int) bool {
 
 	for i := range l {
 		for j := range l[i].TestSuites {
-			for k := range l[i].TestSuites[j].TestRuns {
-				if l[i].TestSuites[j].TestRuns[k].ID == id {
-					l[i].TestSuites[j].TestRuns[k].Selected = !l[i].TestSuites[j].TestRuns[k].Selected
-					found = true
-				} else {
-					l[i].TestSuites[j].TestRuns[k].Selected = false
-				}
+			if l[i].TestSuites[j].toggleTestRuns(id) {
+				found = true
 			}
 		}
 	}
@@ -120,17 +117,61 @@ func (l Logs) toggleTestSuites(id int) bool {
 	return found
 }
 
+func (l Logs) toggleBuildFailures(id int) bool {
+	var found bool
+
+	for i := range l {
+		for j := range l[i].BuildFailures {
+			if l[i].BuildFailures[j].ID == id {
+				l[i].BuildFailures[j].Selected = !l[i].BuildFailures[j].Selected
+				found = true
+			} else {
+				l[i].BuildFailures[j].Selected = false
+			}
+		}
+	}
+
+	return found
+}
+
+func (l Logs) togglePackages(id int) bool {
+	var found bool
+
+	for i := range l {
+		for j := range l[i].Packages {
+			if l[i].Packages[j].ID == id {
+				l[i].Packages[j].Selected = !l[i].Packages[j].Selected
+				found = true
+			} else {
+				l[i].Packages[j].Selected = false
+			}
+		}
+	}
+
+	return found
+}
+
 func (l Logs) Toggle(id int) {
 	ok := l.toggleTestRuns(id)
 	if ok {
 		return
 	}
 
+	ok = l.toggleBuildFailures(id)
+	if ok {
+		return
+	}
+
 	ok = l.toggleTestSuites(id)
 	if ok {
 		return
 	}
 
+	ok = l.togglePackages(id)
+	if ok {
+		return
+	}
+
 	for i := range l {
 		if l[i].ID == id {
 			l[i].Selected = !l[i].Selected
@@ -140,6 +181,32 @@ func (l Logs) Toggle(id int) {
 	}
 }
 
+// FailedPackageNames are the packages of the failed tests, starting with
+// the ones of the tests whose output is showing
+func (l Logs) FailedPackageNames() []string {
+	var names []string
+
+	for _, s := range l {
+		for _, suite := range s.TestSuites {
+			for _, run := range suite.AllTestRuns() {
+				if run.Selected && run.Failed() && suite.Package != "" && !containsString(names, suite.Package) {
+					names = append(names, suite.Package)
+				}
+			}
+		}
+	}
+
+	for _, s := range l {
+		for _, suite := range s.FailedTestSuites() {
+			if suite.Package != "" && !containsString(names, suite.Package) {
+				names = append(names, suite.Package)
+			}
+		}
+	}
+
+	return names
+}
+
 func (l Logs) TestCount() int {
 	var count int
 
@@ -152,6 +219,86 @@ func (l Logs) TestCount() int {
 	return count
 }
 
+// FailureCount is the number of failed tests, as tallied by each suite
+func (l Logs) FailureCount() int {
+	var (
+		count          int
+		failureMatcher = regexp.MustCompile(`Failed: (\d+)`)
+	)
+
+	for _, s := range l {
+		for _, suite := range s.TestSuites {
+			matches := failureMatcher.FindStringSubmatch(suite.Title)
+			if len(matches) == 2 {
+				failed, _ := strconv.Atoi(matches[1])
+				count = count + failed
+			}
+		}
+	}
+
+	return count
+}
+
+func (l Logs) SkipCount() int {
+	var count int
+
+	for _, s := range l {
+		for _, suite := range s.TestSuites {
+			count = count + len(suite.SkippedTestRuns())
+		}
+	}
+
+	return count
+}
+
+func (l Logs) DataRaceCount() int {
+	var count int
+
+	for _, s := range l {
+		count = count + len(s.DataRaces())
+	}
+
+	return count
+}
+
+func (l Logs) BenchmarkCount() int {
+	var count int
+
+	for _, s := range l {
+		count = count + len(s.Benchmarks)
+	}
+
+	return count
+}
+
+func (l Logs) BuildFailureCount() int {
+	var count int
+
+	for _, s := range l {
+		count = count + len(s.BuildFailures)
+	}
+
+	return count
+}
+
+// Failed reports whether anything failed, be it a test, a package,
+// a build or a benchmark, or the step as a whole
+func (l Logs) Failed() bool {
+	for _, s := range l {
+		if !s.Success || len(s.FailedTestSuites()) != 0 || len(s.BuildFailures) != 0 || len(s.FailedBenchmarks()) != 0 || s.HaveUnhandledFailures() {
+			return true
+		}
+
+		for _, pkg := range s.Packages {
+			if !pkg.Success {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func (l Logs) HaveUnhandledFailures() bool {
 	for _, s := range l {
 		if s.HaveUnhandledFailures() {
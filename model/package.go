@@ -0,0 +1,60 @@
+package model
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Package is the result go test prints for
+// a package once all of its tests are done
+type Package struct {
+	ID       int
+	Name     string
+	Selected bool
+	Success  bool
+	Cached   bool
+	Elapsed  time.Duration
+}
+
+// PackageDir finds the directory of a package in the module that go-swt
+// is run from, for files that go test names relative to their package
+func PackageDir(pkg string) (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		contents, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			module := modulePath(string(contents))
+			if pkg != module && !strings.HasPrefix(pkg, module+"/") {
+				return "", fmt.Errorf("package %s is not part of module %s", pkg, module)
+			}
+
+			return filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(pkg, module))), nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("unable to find go.mod for package %s", pkg)
+		}
+
+		dir = parent
+	}
+}
+
+func modulePath(goMod string) string {
+	for _, line := range strings.Split(goMod, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+
+	return ""
+}
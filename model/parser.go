@@ -1,15 +1,26 @@
 package model
 
 import (
-	"bufio"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"path"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// outputParser parses the output of a test framework that go test
+// runs, and reports whether the line was part of it
+type outputParser interface {
+	parseOutput(id *int, step *Step, line string) bool
+
+	// finishPackage is called once go test is done with a package
+	finishPackage()
+}
+
 type Parser struct {
 	suiteMatcher  *regexp.Regexp
 	tallyMatcher  *regexp.Regexp
@@ -17,31 +28,117 @@ type Parser struct {
 	runMatcher    *regexp.Regexp
 	actionMatcher *regexp.Regexp
 	reportMatcher *regexp.Regexp
-	failedMatcher *regexp.Regexp
+	resultMatcher *regexp.Regexp
+
+	panicMatcher    *regexp.Regexp
+	panicEndMatcher *regexp.Regexp
+	timeoutMatcher  *regexp.Regexp
+	runningMatcher  *regexp.Regexp
+	quitMatcher     *regexp.Regexp
+
+	raceMatcher      *regexp.Regexp
+	raceEndMatcher   *regexp.Regexp
+	stackFileMatcher *regexp.Regexp
+
+	fuzzInputMatcher *regexp.Regexp
+	fuzzEntryMatcher *regexp.Regexp
+	corpusMatcher    *regexp.Regexp
+
+	benchmarkMatcher       *regexp.Regexp
+	benchmarkNameMatcher   *regexp.Regexp
+	benchmarkReportMatcher *regexp.Regexp
+
+	packageMatcher    *regexp.Regexp
+	buildMatcher      *regexp.Regexp
+	buildEndMatcher   *regexp.Regexp
+	diagnosticMatcher *regexp.Regexp
 
 	suiteIndexMapping map[string]int
-	runIndexMapping   map[string]map[string]int
+	runIndexMapping   map[string]map[string][]int
+	runSuiteMapping   map[string][]string
 	currentTestSuite  string
 	currentTestRun    string
 
+	// output printed beneath a report line, indented further than it,
+	// belongs to the test being reported on
+	reportIndent   int
+	reportingOnRun bool
+
 	// workaround to capture main text before loaded
-	mainTestRunName string
-	mainTestLines   []string
+	mainTestRunName  string
+	mainTestLines    []string
+	mainTestHasSuite bool
+
+	// suites without a tally printed by spec, either because they were
+	// built from plain `testing` subtests or because the tests crashed,
+	// that need one once the main test run is over
+	untalliedTestSuites []string
+
+	// everything from a panic until the package's FAIL line
+	// is the crash output of the tests that were running
+	panickedTestRuns  []string
+	lastFailedTestRun string
+
+	// a timeout panic is followed by the tests that were
+	// still running, which the rest of the crash is about
+	timingOut        bool
+	timeoutLines     []string
+	timedOutTestRuns map[string]time.Duration
+
+	// stopping go test with SIGQUIT has the tests print the stack
+	// of every goroutine, which tells where the running tests were
+	quitting  bool
+	quitLines []string
+
+	// a race detector report, up until its closing line of "="s,
+	// is about the test that was running
+	racingTestRun string
 
-	doneChan       chan bool
-	testSuiteChan  chan TestSuite
-	lineChan       chan string
-	testSuiteIndex int
+	// benchmarks aren't tests, so their results and output
+	// are kept apart from everything else, by name and GOMAXPROCS
+	benchmarkIndexMapping map[string]int
+	currentBenchmark      string
+	currentBenchmarkProcs int
+
+	// the output of a test framework that go test runs,
+	// like Ginkgo, is parsed before go test's own output
+	output outputParser
+
+	// json output that doesn't end a line yet
+	partialOutput string
+
+	// compiler output beneath a "# pkg" header
+	// belongs to that package's build failure
+	buildFailureIndexMapping map[string]int
+	currentBuildFailure      string
+
+	doneChan          chan bool
+	testSuiteChan     chan TestSuite
+	buildFailureChan  chan BuildFailure
+	packageChan       chan Package
+	benchmarkChan     chan Benchmark
+	lineChan          chan string
+	testSuiteIndex    int
+	buildFailureIndex int
+	benchmarkIndex    int
 }
 
-func NewParser(testSuiteChan chan TestSuite, lineChan chan string, doneChan chan bool) *Parser {
+func NewParser(testSuiteChan chan TestSuite, buildFailureChan chan BuildFailure, packageChan chan Package, benchmarkChan chan Benchmark, lineChan chan string, doneChan chan bool) *Parser {
 	return &Parser{
-		suiteIndexMapping: map[string]int{},
-		runIndexMapping:   map[string]map[string]int{},
-		testSuiteChan:     testSuiteChan,
-		lineChan:          lineChan,
-		doneChan:          doneChan,
-		testSuiteIndex:    0,
+		suiteIndexMapping:        map[string]int{},
+		runIndexMapping:          map[string]map[string][]int{},
+		runSuiteMapping:          map[string][]string{},
+		buildFailureIndexMapping: map[string]int{},
+		benchmarkIndexMapping:    map[string]int{},
+		testSuiteChan:            testSuiteChan,
+		buildFailureChan:         buildFailureChan,
+		packageChan:              packageChan,
+		benchmarkChan:            benchmarkChan,
+		lineChan:                 lineChan,
+		doneChan:                 doneChan,
+		testSuiteIndex:           0,
+		buildFailureIndex:        0,
+		benchmarkIndex:           0,
 
 		suiteMatcher:  regexp.MustCompile(`^Suite: .+$`),
 		tallyMatcher:  regexp.MustCompile(`^Passed: \d+ | Failed: \d+ | Skipped: \d+$`),
@@ -49,67 +146,228 @@ func NewParser(testSuiteChan chan TestSuite, lineChan chan string, doneChan chan
 		runMatcher:    regexp.MustCompile(`^=== RUN\s+(\S+)$`),
 		actionMatcher: regexp.MustCompile(`^=== [A-Z]+\s+(\S+)$`),
 		reportMatcher: regexp.MustCompile(`^--- [A-Z]+: (\S+) \(.+$`),
-		failedMatcher: regexp.MustCompile(`^\s*--- FAIL: (\S+) \(.+$`),
+		resultMatcher: regexp.MustCompile(`^\s*--- (PASS|FAIL|SKIP): (\S+) \(([\d.]+)s\)`),
+
+		panicMatcher:    regexp.MustCompile(`^(panic|fatal error): `),
+		panicEndMatcher: regexp.MustCompile(`^(FAIL|exit status \d+)(\s|$)`),
+		timeoutMatcher:  regexp.MustCompile(`^panic: test timed out after \S+$`),
+		runningMatcher:  regexp.MustCompile(`^\t\t(\S+) \((\S+)\)$`),
+		quitMatcher:     regexp.MustCompile(`^SIGQUIT: quit$`),
+
+		raceMatcher:      regexp.MustCompile(`^WARNING: DATA RACE$`),
+		raceEndMatcher:   regexp.MustCompile(`^={10,}$`),
+		stackFileMatcher: regexp.MustCompile(`^\s+(\S+\.go):(\d+)(\s|$)`),
+
+		fuzzInputMatcher: regexp.MustCompile(`^\s*Failing input written to (\S+)$`),
+		fuzzEntryMatcher: regexp.MustCompile(`^failure while testing seed corpus entry: (\S+)$`),
+		corpusMatcher:    regexp.MustCompile(`^Fuzz[^a-z]\S*/[^/]+$`),
+
+		benchmarkMatcher:       regexp.MustCompile(`^(Benchmark\S*?)(?:-(\d+))?\s+(\d+)\s+(\d.*)$`),
+		benchmarkNameMatcher:   regexp.MustCompile(`^(Benchmark\S*?)(?:-(\d+))?$`),
+		benchmarkReportMatcher: regexp.MustCompile(`^--- (FAIL|BENCH|SKIP): (Benchmark\S*?)(?:-(\d+))?$`),
+
+		packageMatcher:    regexp.MustCompile(`^(ok|FAIL)\s+(\S+)\s+(\(cached\)|\d+(\.\d+)?s|\[(build|setup) failed\])`),
+		buildMatcher:      regexp.MustCompile(`^# (\S+)( \[\S+\])?$`),
+		buildEndMatcher:   regexp.MustCompile(`^(=== |--- |ok\s|FAIL(\s|$)|PASS$|\?\s)`),
+		diagnosticMatcher: regexp.MustCompile(`^(\S+\.go):(\d+)(?::(\d+))?: (.+)$`),
 	}
 }
 
-func (p *Parser) ParseGoTestStep(id *int, step *Step) {
+func (p *Parser) ParseStep(id *int, step *Step) {
 	for _, line := range step.Lines {
 		p.parseGoTestLine(id, step, line)
 	}
+
+	p.finishTimeout(id, step)
+	p.finishQuit(id, step)
+	p.tallyTestSuites(step)
 }
 
-func (p *Parser) ParseGoTestStdin(stdin io.Reader) {
+func (p *Parser) ParseStdin(stdin io.Reader) error {
 	var (
-		id      = 1
-		scanner = bufio.NewScanner(stdin)
-		step    = Step{}
+		id   = 1
+		step = Step{}
 	)
 
-	for scanner.Scan() {
-		p.parseGoTestLine(&id, &step, scanner.Text())
-	}
+	err := ScanLines(stdin, func(line string) {
+		p.parseGoTestLine(&id, &step, line)
+	})
+
+	p.finishTimeout(&id, &step)
+	p.finishQuit(&id, &step)
+	p.tallyTestSuites(&step)
 
-	// ignore the Err() on purpose
-	// _ = scanner.Err()
 	if p.doneChan != nil {
 		p.sendTestSuites(&step)
+		p.sendBuildFailures(&step)
+		p.sendBenchmarks(&step)
 
 		time.Sleep(time.Millisecond)
 		p.doneChan <- true
 	}
+
+	return err
 }
 
 func (p *Parser) parseGoTestLine(id *int, step *Step, line string) {
-	defer p.sendLine(line)
+	event, ok := parseTestEvent(line)
+	if ok {
+		p.parseGoTestEvent(id, step, event)
+		return
+	}
 
-	if p.suiteMatcher.MatchString(line) {
-		step.TestSuites = append(step.TestSuites, TestSuite{
-			ID:    *id,
-			Title: line,
-
-			TestRuns: []TestRun{
-				{
-					ID:      *id + 1,
-					Name:    p.mainTestRunName,
-					Lines:   p.mainTestLines,
-					Success: true,
-				},
-			},
-		})
+	p.parseGoTestText(id, step, line)
+}
+
+// parseGoTestEvent handles a single line of `go test -json` output.
+// Test status and timing come straight from the event, while the
+// embedded output is still run through the text parser so that
+// spec banners (Suite, Total, Passed) are recognized.
+func (p *Parser) parseGoTestEvent(id *int, step *Step, event TestEvent) {
+	// benchmarks are followed through their output instead
+	if strings.HasPrefix(event.Test, "Benchmark") && event.Action != "output" {
+		return
+	}
 
-		*id = *id + 2
+	switch event.Action {
+	case "run":
+		p.startTestRun(id, step, event.Test)
+	case "build-output":
+		line := strings.TrimRight(event.Output, "\r\n")
 
-		p.currentTestSuite = line
+		// the compiler runs before any test
 		p.currentTestRun = ""
-		p.suiteIndexMapping[line] = len(step.TestSuites) - 1
+		p.parseBuildOutput(id, step, line)
+		p.sendLine(line)
+	case "build-fail":
+		p.currentBuildFailure = ""
+	case "output":
+		// a benchmark's name is printed before it runs, and its results after
+		if !strings.HasSuffix(event.Output, "\n") {
+			p.partialOutput = p.partialOutput + event.Output
+			return
+		}
+
+		line := strings.TrimRight(p.partialOutput+event.Output, "\r\n")
+		p.partialOutput = ""
 
-		if p.runIndexMapping[line] == nil {
-			p.runIndexMapping[line] = map[string]int{
-				p.mainTestRunName: 0,
+		// output that isn't from a test comes after any crash output
+		if event.Test == "" {
+			p.finishTimeout(id, step)
+			p.panickedTestRuns = nil
+			p.parsePackageResult(id, step, line)
+
+			// only the first result of a benchmark
+			// that runs more than once is attributed to it
+			p.parseBenchmark(id, step, line)
+		}
+
+		// framing lines are already represented by their own events
+		if event.Test == "" || p.actionMatcher.MatchString(line) || p.reportMatcher.MatchString(strings.TrimSpace(line)) {
+			p.sendLine(line)
+			return
+		}
+
+		p.currentTestRun = event.Test
+		p.reportingOnRun = false
+		p.parseGoTestText(id, step, line)
+	case "pass", "fail", "skip":
+		// the result of a package as a whole
+		if event.Test == "" {
+			return
+		}
+
+		p.recordTestResult(id, step, event.Test, strings.ToUpper(event.Action))
+		p.finishTestRun(step, event.Test, event.Elapsed)
+	}
+}
+
+func (p *Parser) parseGoTestText(id *int, step *Step, line string) {
+	defer p.sendLine(line)
+
+	if p.parsePackageResult(id, step, line) {
+		return
+	}
+
+	if p.timingOut {
+		if line == "\trunning tests:" {
+			p.timeoutLines = append(p.timeoutLines, line)
+			return
+		}
+
+		runningMatches := p.runningMatcher.FindStringSubmatch(line)
+		if len(runningMatches) == 3 {
+			elapsed, _ := time.ParseDuration(runningMatches[2])
+
+			p.timedOutTestRuns[runningMatches[1]] = elapsed
+			p.timeoutLines = append(p.timeoutLines, line)
+			return
+		}
+
+		p.finishTimeout(id, step)
+	}
+
+	if p.quitting {
+		if !p.panicEndMatcher.MatchString(line) {
+			p.quitLines = append(p.quitLines, line)
+			return
+		}
+
+		p.finishQuit(id, step)
+	}
+
+	if len(p.panickedTestRuns) != 0 {
+		if p.panicEndMatcher.MatchString(line) {
+			p.panickedTestRuns = nil
+			p.currentTestRun = ""
+			return
+		}
+
+		for _, name := range p.panickedTestRuns {
+			for _, run := range p.testRuns(step, name) {
+				run.Lines = append(run.Lines, line)
 			}
 		}
+		return
+	}
+
+	if p.parseBuildOutput(id, step, line) {
+		return
+	}
+
+	if p.timeoutMatcher.MatchString(line) {
+		p.timingOut = true
+		p.timeoutLines = []string{line}
+		p.timedOutTestRuns = map[string]time.Duration{}
+		return
+	}
+
+	if p.quitMatcher.MatchString(line) {
+		p.quitting = true
+		p.quitLines = []string{line}
+		return
+	}
+
+	if p.panicMatcher.MatchString(line) {
+		p.startPanic(id, step, line)
+		return
+	}
+
+	if p.parseBenchmark(id, step, line) {
+		return
+	}
+
+	p.parseDataRace(id, step, line)
 
+	if p.output != nil && p.output.parseOutput(id, step, line) {
+		return
+	}
+
+	if p.suiteMatcher.MatchString(line) {
+		p.startTestSuite(id, step, line)
+
+		p.currentTestSuite = line
+		p.currentTestRun = ""
 		return
 	}
 
@@ -137,80 +395,812 @@ func (p *Parser) parseGoTestLine(id *int, step *Step, line string) {
 
 	runMatches := p.runMatcher.FindStringSubmatch(line)
 	if len(runMatches) == 2 {
-		p.currentTestRun = runMatches[1]
+		p.startTestRun(id, step, runMatches[1])
+		return
+	}
+
+	// PAUSE, CONT and NAME say which parallel test the following output is from
+	actionMatches := p.actionMatcher.FindStringSubmatch(line)
+	if len(actionMatches) == 2 {
+		p.currentTestRun = actionMatches[1]
+		p.reportingOnRun = false
+		return
+	}
+
+	if p.reportMatcher.MatchString(line) {
+		p.currentTestSuite = ""
+		p.currentTestRun = ""
+	}
+
+	// the fuzzing engine reports the failure of the target
+	// once more, beneath go test's report of it
+	resultMatches := p.resultMatcher.FindStringSubmatch(line)
+	if p.reportingOnRun && len(resultMatches) == 4 && resultMatches[2] == p.currentTestRun && indentOf(line) > p.reportIndent {
+		resultMatches = nil
+	}
 
-		if p.currentTestSuite == "" {
+	if len(resultMatches) == 4 {
+		elapsed, _ := strconv.ParseFloat(resultMatches[3], 64)
+
+		p.recordTestResult(id, step, resultMatches[2], resultMatches[1])
+		p.finishTestRun(step, resultMatches[2], elapsed)
+
+		p.currentTestRun = resultMatches[2]
+		p.reportIndent = indentOf(line)
+		p.reportingOnRun = true
+		return
+	}
+
+	if p.reportingOnRun && indentOf(line) <= p.reportIndent {
+		p.currentTestRun = ""
+		p.reportingOnRun = false
+	}
+
+	if p.currentTestRun == "" {
+		return
+	}
+
+	p.parseFuzzFailure(id, step, line)
+
+	runs := p.testRuns(step, p.currentTestRun)
+	if len(runs) == 0 && p.currentTestRun == p.mainTestRunName {
+		p.mainTestLines = append(p.mainTestLines, line)
+		return
+	}
+
+	for _, run := range runs {
+		// throw away blank first lines
+		if strings.TrimSpace(line) == "" && len(run.Lines) == 0 {
+			continue
+		}
+
+		run.Lines = append(run.Lines, line)
+	}
+}
+
+func (p *Parser) startTestSuite(id *int, step *Step, title string) {
+	step.TestSuites = append(step.TestSuites, TestSuite{
+		ID:    *id,
+		Title: title,
+
+		TestRuns: []TestRun{
+			{
+				ID:      *id + 1,
+				Name:    p.mainTestRunName,
+				Lines:   append([]string{}, p.mainTestLines...),
+			},
+		},
+	})
+
+	*id = *id + 2
+
+	p.mainTestHasSuite = true
+	p.suiteIndexMapping[title] = len(step.TestSuites) - 1
+	p.runSuiteMapping[p.mainTestRunName] = append(p.runSuiteMapping[p.mainTestRunName], title)
+
+	if p.runIndexMapping[title] == nil {
+		p.runIndexMapping[title] = map[string][]int{
+			p.mainTestRunName: {0},
+		}
+	}
+}
+
+// startPlainTestSuite creates a suite named after the main test run,
+// for output that has no spec banners to build suites from
+func (p *Parser) startPlainTestSuite(id *int, step *Step) {
+	p.startTestSuite(id, step, p.mainTestRunName)
+	step.TestSuites[len(step.TestSuites)-1].Name = p.mainTestRunName
+	p.untalliedTestSuites = append(p.untalliedTestSuites, p.mainTestRunName)
+}
+
+func (p *Parser) startTestRun(id *int, step *Step, name string) {
+	p.currentTestRun = name
+	p.reportingOnRun = false
+
+	if p.currentTestSuite == "" {
+		if p.mainTestRunName == "" || !strings.HasPrefix(name, p.mainTestRunName+"/") {
 			// This represents parsing a new set of test suites
+			p.tallyTestSuites(step)
 			p.mainTestRunName = p.currentTestRun
 			p.mainTestLines = []string{}
+			p.mainTestHasSuite = false
 			p.sendTestSuites(step)
 			return
 		}
 
-		si, ok := p.suiteIndexMapping[p.currentTestSuite]
-		if ok {
-			step.TestSuites[si].TestRuns = append(step.TestSuites[si].TestRuns, TestRun{
-				ID:      *id,
-				Name:    p.currentTestRun,
-				Success: true,
-			})
+		// a subtest started without a spec banner before it
+		p.startPlainTestSuite(id, step)
+		p.currentTestSuite = p.mainTestRunName
+	}
 
-			p.runIndexMapping[p.currentTestSuite][p.currentTestRun] = len(step.TestSuites[si].TestRuns) - 1
+	si, ok := p.suiteIndexMapping[p.currentTestSuite]
+	if ok {
+		p.addTestRun(id, &step.TestSuites[si], p.currentTestSuite, name)
+	}
+}
 
-			*id = *id + 1
-		}
+// addTestRun places a run in the suite's tree. Runs are nested by the
+// "/" separated parts of their name below the suite's name, with
+// a placeholder run for any part that never ran on its own, which
+// is how spec reports its nested "when" blocks.
+func (p *Parser) addTestRun(id *int, suite *TestSuite, key string, name string) {
+	runIndexMapping := p.runIndexMapping[key]
+
+	if suite.Name == "" {
+		// spec runs everything under the first test after its banner
+		suite.Name = name
+	}
 
+	if !strings.HasPrefix(name, suite.Name+"/") {
+		runIndexMapping[name] = suite.addTestRun(nil, TestRun{ID: *id, Name: name})
+		p.runSuiteMapping[name] = []string{key}
+		*id = *id + 1
 		return
 	}
 
-	actionMatches := p.actionMatcher.FindStringSubmatch(line)
-	if len(actionMatches) == 2 {
-		if p.currentTestSuite == "" {
-			return
+	p.addNestedTestRun(id, suite, key, strings.Split(strings.TrimPrefix(name, suite.Name+"/"), "/"))
+}
+
+// addNestedTestRun places a run below the suite's name, one level for each
+// part of its name, and returns the name it was given
+func (p *Parser) addNestedTestRun(id *int, suite *TestSuite, key string, parts []string) string {
+	var (
+		path            []int
+		fullName        = suite.Name
+		runIndexMapping = p.runIndexMapping[key]
+	)
+
+	for i, part := range parts {
+		fullName = fullName + "/" + part
+
+		existing, ok := runIndexMapping[fullName]
+		if ok && i != len(parts)-1 {
+			path = existing
+			continue
 		}
 
-		p.currentTestRun = actionMatches[1]
-		return
+		path = suite.addTestRun(path, TestRun{ID: *id, Name: fullName, CorpusFile: p.corpusFile(fullName)})
+		runIndexMapping[fullName] = path
+		p.runSuiteMapping[fullName] = []string{key}
+		*id = *id + 1
 	}
 
-	if p.reportMatcher.MatchString(line) {
+	return fullName
+}
+
+// testRuns finds the run with the given name in each suite it is part of,
+// which is only ever more than one for a main test run split up by spec
+func (p *Parser) testRuns(step *Step, name string) []*TestRun {
+	var runs []*TestRun
+
+	for _, key := range p.runSuiteMapping[name] {
+		suite := &step.TestSuites[p.suiteIndexMapping[key]]
+		runs = append(runs, suite.testRunAt(p.runIndexMapping[key][name]))
+	}
+	return runs
+}
+
+func (p *Parser) setTestRunStatus(step *Step, name string, status TestStatus) {
+	for _, run := range p.testRuns(step, name) {
+		run.Status = status
+	}
+}
+
+func (p *Parser) recordTestResult(id *int, step *Step, name string, result string) {
+	if name == p.mainTestRunName && !p.mainTestHasSuite {
+		// a main test run without subtests is a suite of its own
+		p.startPlainTestSuite(id, step)
+	}
+
+	switch result {
+	case "PASS":
+		p.setTestRunStatus(step, name, TestPassed)
+	case "SKIP":
+		p.setTestRunStatus(step, name, TestSkipped)
+	case "FAIL":
+		p.setTestRunStatus(step, name, TestFailed)
+		p.lastFailedTestRun = name
+	}
+}
+
+func (p *Parser) finishTestRun(step *Step, name string, elapsed float64) {
+	for _, run := range p.testRuns(step, name) {
+		run.Elapsed = time.Duration(elapsed * float64(time.Second))
+		run.Assertions = parseAssertions(run.Lines)
+	}
+
+	// a suite takes as long as the run that the rest are nested under
+	for _, key := range p.runSuiteMapping[name] {
+		suite := &step.TestSuites[p.suiteIndexMapping[key]]
+		if suite.Name == name {
+			suite.Elapsed = time.Duration(elapsed * float64(time.Second))
+		}
+	}
+
+	// a top-level test finishing closes out its suites,
+	// just like an unindented report line does
+	if name == p.mainTestRunName {
 		p.currentTestSuite = ""
 		p.currentTestRun = ""
 	}
+}
+
+// startPanic attaches a crash to the test that caused it. When the
+// panic happened in the test itself, go reports it as failed first.
+// Otherwise the panic came from a goroutine of whichever test was running.
+func (p *Parser) startPanic(id *int, step *Step, line string) {
+	name := p.currentTestRun
+	if name == "" || strings.HasPrefix(p.lastFailedTestRun, name+"/") {
+		name = p.lastFailedTestRun
+	}
+
+	if name == p.mainTestRunName && !p.mainTestHasSuite {
+		p.startPlainTestSuite(id, step)
+	}
+
+	runs := p.testRuns(step, name)
+	if len(runs) == 0 {
+		return
+	}
+
+	p.panickedTestRuns = []string{name}
+	p.setTestRunStatus(step, name, TestFailed)
+
+	for _, run := range runs {
+		run.Panicked = true
+		run.Lines = append(run.Lines, line)
+	}
 
-	failureMatches := p.failedMatcher.FindStringSubmatch(line)
-	if len(failureMatches) == 2 {
-		testRun := failureMatches[1]
+	p.untallyTestSuites(step, name)
+}
 
-		for k, si := range p.suiteIndexMapping {
-			ri, ok := p.runIndexMapping[k][testRun]
-			if ok {
-				step.TestSuites[si].TestRuns[ri].Success = false
+// finishTimeout marks the tests that go test listed as running when it
+// timed out, or when it is too old to list them, the ones without a result.
+// The rest of the crash output goes to each of them.
+func (p *Parser) finishTimeout(id *int, step *Step) {
+	if !p.timingOut {
+		return
+	}
+
+	p.timingOut = false
+
+	if len(p.timedOutTestRuns) == 0 {
+		for _, key := range p.runSuiteMapping[p.mainTestRunName] {
+			for _, run := range step.TestSuites[p.suiteIndexMapping[key]].runningTestRuns() {
+				p.timedOutTestRuns[run.Name] = 0
 			}
 		}
+	}
 
+	// a main test run without subtests has no suite yet
+	if len(p.timedOutTestRuns) == 0 && p.mainTestRunName != "" {
+		p.timedOutTestRuns[p.mainTestRunName] = 0
+	}
+
+	p.panickedTestRuns = nil
+
+	for name, elapsed := range p.timedOutTestRuns {
+		if name == p.mainTestRunName && !p.mainTestHasSuite {
+			p.startPlainTestSuite(id, step)
+		}
+
+		runs := p.testRuns(step, name)
+		if len(runs) == 0 {
+			continue
+		}
+
+		for _, run := range runs {
+			run.Status = TestTimedOut
+			run.Elapsed = elapsed
+			run.Lines = append(run.Lines, p.timeoutLines...)
+		}
+
+		p.panickedTestRuns = append(p.panickedTestRuns, name)
+		p.untallyTestSuites(step, name)
+	}
+}
+
+// finishQuit marks the tests that were running when go test was stopped,
+// giving them the stacks of the goroutines that were running their code
+func (p *Parser) finishQuit(id *int, step *Step) {
+	if !p.quitting {
 		return
 	}
 
-	if p.currentTestRun != "" {
-		if p.currentTestSuite == "" {
-			p.mainTestLines = append(p.mainTestLines, line)
+	p.quitting = false
+
+	var names []string
+	for _, key := range p.runSuiteMapping[p.mainTestRunName] {
+		for _, run := range step.TestSuites[p.suiteIndexMapping[key]].runningTestRuns() {
+			if !containsString(names, run.Name) {
+				names = append(names, run.Name)
+			}
+		}
+	}
+
+	// a main test run without subtests has no suite yet
+	if len(names) == 0 && p.mainTestRunName != "" {
+		names = []string{p.mainTestRunName}
+	}
+
+	lines := goroutineStacks(p.quitLines, p.mainTestRunName)
+
+	for _, name := range names {
+		if name == p.mainTestRunName && !p.mainTestHasSuite {
+			p.startPlainTestSuite(id, step)
+		}
+
+		for _, run := range p.testRuns(step, name) {
+			run.Status = TestStopped
+			run.Lines = append(run.Lines, lines...)
+		}
+
+		p.untallyTestSuites(step, name)
+	}
+}
+
+// untallyTestSuites makes sure the suites of a test that crashed get a
+// tally, since spec never gets to print one for them
+func (p *Parser) untallyTestSuites(step *Step, name string) {
+	for _, key := range p.runSuiteMapping[name] {
+		si := p.suiteIndexMapping[key]
+		if !strings.Contains(step.TestSuites[si].Title, "Failed:") && !containsString(p.untalliedTestSuites, key) {
+			p.untalliedTestSuites = append(p.untalliedTestSuites, key)
+		}
+	}
+}
+
+// parsePackageResult handles the "ok" or "FAIL" line that go test prints
+// once it is done with a package, which closes out everything before it
+func (p *Parser) parsePackageResult(id *int, step *Step, line string) bool {
+	matches := p.packageMatcher.FindStringSubmatch(line)
+	if len(matches) != 6 {
+		return false
+	}
+
+	p.finishTimeout(id, step)
+	p.finishQuit(id, step)
+
+	pkg := Package{
+		ID:      *id,
+		Name:    matches[2],
+		Success: matches[1] == "ok",
+		Cached:  matches[3] == "(cached)",
+	}
+
+	*id = *id + 1
+
+	switch {
+	case matches[5] != "":
+		// a package whose dependency failed to build gets only this line
+		p.startBuildFailure(id, step, pkg.Name)
+	case !pkg.Cached:
+		pkg.Elapsed, _ = time.ParseDuration(matches[3])
+	}
+
+	// benchmarks are only sent along with their package
+	for i := p.benchmarkIndex; i < len(step.Benchmarks); i++ {
+		step.Benchmarks[i].Package = pkg.Name
+	}
+
+	p.tallyTestSuites(step)
+	step.AddPackage(pkg)
+	p.readCorpusFiles(step)
+
+	p.sendTestSuites(step)
+	p.sendBuildFailures(step)
+	p.sendBenchmarks(step)
+	p.sendPackage(pkg)
+
+	// test names are only unique within a package
+	p.suiteIndexMapping = map[string]int{}
+	p.runIndexMapping = map[string]map[string][]int{}
+	p.runSuiteMapping = map[string][]string{}
+	p.currentTestSuite = ""
+	p.currentTestRun = ""
+	p.reportingOnRun = false
+	p.mainTestRunName = ""
+	p.mainTestLines = nil
+	p.mainTestHasSuite = false
+	p.panickedTestRuns = nil
+	p.lastFailedTestRun = ""
+	p.racingTestRun = ""
+	p.benchmarkIndexMapping = map[string]int{}
+	p.currentBenchmark = ""
+	p.currentBuildFailure = ""
+
+	if p.output != nil {
+		p.output.finishPackage()
+	}
+
+	return true
+}
+
+// parseBuildOutput collects what the compiler printed for a package
+// that failed to build, and reports whether the line was part of it
+func (p *Parser) parseBuildOutput(id *int, step *Step, line string) bool {
+	// a test printing something like a header is not the compiler
+	buildMatches := p.buildMatcher.FindStringSubmatch(line)
+	if len(buildMatches) == 3 && p.currentTestRun == "" {
+		p.startBuildFailure(id, step, buildMatches[1])
+		p.currentBuildFailure = buildMatches[1]
+		return true
+	}
+
+	if p.currentBuildFailure == "" {
+		return false
+	}
+
+	if p.buildEndMatcher.MatchString(line) {
+		p.currentBuildFailure = ""
+		return false
+	}
+
+	bf := &step.BuildFailures[p.buildFailureIndexMapping[p.currentBuildFailure]]
+	bf.Lines = append(bf.Lines, line)
+
+	diagnosticMatches := p.diagnosticMatcher.FindStringSubmatch(line)
+	if len(diagnosticMatches) == 5 {
+		lineNum, _ := strconv.Atoi(diagnosticMatches[2])
+		column, _ := strconv.Atoi(diagnosticMatches[3])
+
+		bf.Diagnostics = append(bf.Diagnostics, Diagnostic{
+			File:    diagnosticMatches[1],
+			Line:    lineNum,
+			Column:  column,
+			Message: diagnosticMatches[4],
+		})
+	}
+
+	return true
+}
+
+// startBuildFailure is a no-op for a package that already failed,
+// since its test files are compiled apart from the rest of it
+func (p *Parser) startBuildFailure(id *int, step *Step, pkg string) {
+	_, ok := p.buildFailureIndexMapping[pkg]
+	if ok {
+		return
+	}
+
+	step.BuildFailures = append(step.BuildFailures, BuildFailure{
+		ID:      *id,
+		Package: pkg,
+	})
+
+	*id = *id + 1
+	p.buildFailureIndexMapping[pkg] = len(step.BuildFailures) - 1
+}
+
+// parseDataRace pulls a race detector report out of the output of
+// the test that was running. The report stays in the test's output too.
+func (p *Parser) parseDataRace(id *int, step *Step, line string) {
+	if p.raceMatcher.MatchString(line) {
+		name := p.currentTestRun
+		if name == "" {
 			return
 		}
 
-		for k, si := range p.suiteIndexMapping {
-			ri, ok := p.runIndexMapping[k][p.currentTestRun]
-			if ok {
-				// throw away blank first lines
-				testRunLogCount := len(step.TestSuites[si].TestRuns[ri].Lines)
-				if strings.TrimSpace(line) == "" && testRunLogCount == 0 {
-					continue
+		if name == p.mainTestRunName && !p.mainTestHasSuite {
+			p.startPlainTestSuite(id, step)
+		}
+
+		for _, run := range p.testRuns(step, name) {
+			run.DataRaces = append(run.DataRaces, DataRace{Test: name})
+		}
+
+		p.racingTestRun = name
+		return
+	}
+
+	if p.racingTestRun == "" {
+		return
+	}
+
+	if p.raceEndMatcher.MatchString(line) {
+		p.racingTestRun = ""
+		return
+	}
+
+	for _, run := range p.testRuns(step, p.racingTestRun) {
+		p.addDataRaceLine(&run.DataRaces[len(run.DataRaces)-1], line)
+	}
+}
+
+// addDataRaceLine adds to a data race report, in which each section
+// starts with an unindented header, followed by a function and
+// file line for each call of its stack
+func (p *Parser) addDataRaceLine(race *DataRace, line string) {
+	race.Lines = append(race.Lines, line)
+
+	header := strings.TrimSuffix(line, ":")
+
+	switch {
+	case strings.TrimSpace(line) == "":
+		return
+	case strings.HasPrefix(line, "Goroutine "):
+		race.Goroutines = append(race.Goroutines, RaceStack{Header: header})
+		return
+	case indentOf(line) == 0:
+		// anything else, such as "Location is global", has no stack
+		if strings.Contains(line, " at 0x") {
+			race.Accesses = append(race.Accesses, RaceStack{Header: header})
+		}
+		return
+	}
+
+	stack := race.currentStack()
+	if stack == nil {
+		return
+	}
+
+	fileMatches := p.stackFileMatcher.FindStringSubmatch(line)
+	if len(fileMatches) == 4 && len(stack.Frames) != 0 {
+		frame := &stack.Frames[len(stack.Frames)-1]
+		frame.File = fileMatches[1]
+		frame.Line, _ = strconv.Atoi(fileMatches[2])
+		return
+	}
+
+	stack.Frames = append(stack.Frames, StackFrame{Function: strings.TrimSpace(line)})
+}
+
+// parseFuzzFailure adds the input that a fuzz test failed on beneath it,
+// as the subtest that go test runs it as when it is rerun. The input is
+// either newly found by the fuzzing engine and written to the corpus,
+// or an entry of the corpus the test started out with. The crash itself
+// stays in the output of the fuzz test.
+func (p *Parser) parseFuzzFailure(id *int, step *Step, line string) {
+	var name string
+
+	inputMatches := p.fuzzInputMatcher.FindStringSubmatch(line)
+	if len(inputMatches) == 2 {
+		name = path.Base(path.Dir(inputMatches[1])) + "/" + path.Base(inputMatches[1])
+	}
+
+	entryMatches := p.fuzzEntryMatcher.FindStringSubmatch(line)
+	if len(entryMatches) == 2 {
+		name = entryMatches[1]
+	}
+
+	target := p.currentTestRun
+	if name == "" || !strings.HasPrefix(name, target+"/") {
+		return
+	}
+
+	if target == p.mainTestRunName && !p.mainTestHasSuite {
+		p.startPlainTestSuite(id, step)
+	}
+
+	if len(p.testRuns(step, name)) == 0 {
+		for _, key := range p.runSuiteMapping[target] {
+			p.addTestRun(id, &step.TestSuites[p.suiteIndexMapping[key]], key, name)
+		}
+	}
+
+	for _, run := range p.testRuns(step, name) {
+		run.Status = TestFailed
+		run.Lines = append(run.Lines, line)
+	}
+
+	p.untallyTestSuites(step, name)
+}
+
+// corpusFile is where go test keeps the input that
+// a subtest of a fuzz test runs with, unless it is
+// one that the test added itself with f.Add
+func (p *Parser) corpusFile(name string) string {
+	if !p.corpusMatcher.MatchString(name) || strings.HasPrefix(path.Base(name), "seed#") {
+		return ""
+	}
+
+	return path.Join("testdata", "fuzz", name)
+}
+
+// readCorpusFiles reads the inputs that the fuzz tests of the suites yet
+// to be sent failed on, from the directory of their package
+func (p *Parser) readCorpusFiles(step *Step) {
+	for i := p.testSuiteIndex; i < len(step.TestSuites); i++ {
+		suite := &step.TestSuites[i]
+
+		root := TestRun{TestRuns: suite.TestRuns}
+		root.walk(func(run *TestRun) {
+			if run.CorpusFile == "" || !run.Failed() {
+				return
+			}
+
+			run.CorpusLines, run.CorpusError = readCorpusFile(suite.Package, run.CorpusFile)
+		})
+	}
+}
+
+func readCorpusFile(pkg string, file string) ([]string, error) {
+	path := file
+
+	if pkg != "" {
+		dir, err := PackageDir(pkg)
+		if err != nil {
+			return nil, err
+		}
+
+		path = filepath.Join(dir, filepath.FromSlash(file))
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Split(strings.TrimRight(string(contents), "\n"), "\n"), nil
+}
+
+// parseBenchmark collects the results of benchmarks, along with what they
+// printed beneath the line with their name or their report, and reports
+// whether the line was part of them
+func (p *Parser) parseBenchmark(id *int, step *Step, line string) bool {
+	resultMatches := p.benchmarkMatcher.FindStringSubmatch(line)
+	if len(resultMatches) == 5 {
+		sample, ok := parseBenchmarkSample(resultMatches[3], resultMatches[4])
+		if ok {
+			bm := p.benchmark(id, step, resultMatches[1], parseProcs(resultMatches[2]))
+			bm.Samples = append(bm.Samples, sample)
+
+			p.currentBenchmark = ""
+			return true
+		}
+	}
+
+	nameMatches := p.benchmarkNameMatcher.FindStringSubmatch(line)
+	if len(nameMatches) == 3 {
+		p.currentBenchmark = nameMatches[1]
+		p.currentBenchmarkProcs = parseProcs(nameMatches[2])
+		return true
+	}
+
+	reportMatches := p.benchmarkReportMatcher.FindStringSubmatch(line)
+	if len(reportMatches) == 4 {
+		// go test reports a failure by the name without its GOMAXPROCS
+		if reportMatches[3] != "" || reportMatches[2] != p.currentBenchmark {
+			p.currentBenchmarkProcs = parseProcs(reportMatches[3])
+		}
+
+		p.currentBenchmark = reportMatches[2]
+
+		if reportMatches[1] == "FAIL" {
+			p.benchmark(id, step, p.currentBenchmark, p.currentBenchmarkProcs).Failed = true
+		}
+		return true
+	}
+
+	if p.currentBenchmark == "" {
+		return false
+	}
+
+	if indentOf(line) == 0 {
+		p.currentBenchmark = ""
+		return false
+	}
+
+	bm := p.benchmark(id, step, p.currentBenchmark, p.currentBenchmarkProcs)
+	bm.Lines = append(bm.Lines, line)
+	return true
+}
+
+// benchmark finds the benchmark that ran with the given name
+// and GOMAXPROCS, adding it if this is the first sign of it
+func (p *Parser) benchmark(id *int, step *Step, name string, procs int) *Benchmark {
+	key := fmt.Sprintf("%s-%d", name, procs)
+
+	bi, ok := p.benchmarkIndexMapping[key]
+	if !ok {
+		step.Benchmarks = append(step.Benchmarks, Benchmark{
+			ID:    *id,
+			Name:  name,
+			Procs: procs,
+		})
+
+		*id = *id + 1
+		bi = len(step.Benchmarks) - 1
+		p.benchmarkIndexMapping[key] = bi
+	}
+
+	return &step.Benchmarks[bi]
+}
+
+// parseBenchmarkSample reads the iterations and the value and unit
+// pairs of the metrics that follow a benchmark's name
+func parseBenchmarkSample(iterations string, metrics string) (BenchmarkSample, bool) {
+	var (
+		sample = BenchmarkSample{}
+		fields = strings.Fields(metrics)
+	)
+
+	if len(fields)%2 != 0 {
+		return sample, false
+	}
+
+	sample.Iterations, _ = strconv.Atoi(iterations)
+
+	for i := 0; i < len(fields); i = i + 2 {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return sample, false
+		}
+
+		sample.Metrics = append(sample.Metrics, Metric{Value: value, Unit: fields[i+1]})
+	}
+
+	return sample, true
+}
+
+// parseProcs reads the GOMAXPROCS suffix of a benchmark's
+// name, which go test leaves off when it is 1
+func parseProcs(suffix string) int {
+	procs, err := strconv.Atoi(suffix)
+	if err != nil {
+		return 1
+	}
+
+	return procs
+}
+
+// tallyTestSuites gives suites without a spec tally the same
+// "Passed | Failed | Skipped" title that spec prints.
+// Only tests without subtests of their own are counted, and a failure
+// is counted where it happened rather than on every parent.
+func (p *Parser) tallyTestSuites(step *Step) {
+	for _, name := range p.untalliedTestSuites {
+		si, ok := p.suiteIndexMapping[name]
+		if !ok {
+			continue
+		}
+
+		var (
+			passed, failed, skipped, total int
+			suite                          = &step.TestSuites[si]
+			runs                           = suite.AllTestRuns()
+		)
+
+		for _, run := range runs {
+			var (
+				isLeaf      = true
+				childFailed = false
+			)
+
+			for _, other := range runs {
+				if strings.HasPrefix(other.Name, run.Name+"/") {
+					isLeaf = false
+					childFailed = childFailed || other.Status.failed()
 				}
+			}
+
+			switch {
+			case run.Status.failed():
+				if !childFailed {
+					failed = failed + 1
+				}
+			case !isLeaf:
+				// parents are accounted for by their subtests
+			case run.Status == TestSkipped:
+				skipped = skipped + 1
+			case run.Status == TestPassed:
+				passed = passed + 1
+			}
 
-				step.TestSuites[si].TestRuns[ri].Lines = append(step.TestSuites[si].TestRuns[ri].Lines, line)
+			if isLeaf {
+				total = total + 1
 			}
 		}
+
+		// spec suites already know their total
+		if suite.TestCount == 0 {
+			suite.TestCount = total
+		}
+
+		suite.Title = fmt.Sprintf("%s (Passed: %d | Failed: %d | Skipped: %d)", name, passed, failed, skipped)
 	}
+
+	p.untalliedTestSuites = nil
 }
 
 func (p *Parser) sendTestSuites(step *Step) {
@@ -225,6 +1215,50 @@ func (p *Parser) sendTestSuites(step *Step) {
 	p.testSuiteIndex = i
 }
 
+func (p *Parser) sendBuildFailures(step *Step) {
+	var i int
+
+	for i = p.buildFailureIndex; i <= len(step.BuildFailures)-1; i++ {
+		if p.buildFailureChan != nil {
+			p.buildFailureChan <- step.BuildFailures[i]
+		}
+	}
+
+	p.buildFailureIndex = i
+}
+
+func (p *Parser) sendBenchmarks(step *Step) {
+	var i int
+
+	for i = p.benchmarkIndex; i <= len(step.Benchmarks)-1; i++ {
+		if p.benchmarkChan != nil {
+			p.benchmarkChan <- step.Benchmarks[i]
+		}
+	}
+
+	p.benchmarkIndex = i
+}
+
+func (p *Parser) sendPackage(pkg Package) {
+	if p.packageChan != nil {
+		p.packageChan <- pkg
+	}
+}
+
+func containsString(list []string, str string) bool {
+	for _, item := range list {
+		if item == str {
+			return true
+		}
+	}
+
+	return false
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
 func (p *Parser) sendLine(line string) {
 	if p.lineChan != nil {
 		p.lineChan <- line
@@ -2,16 +2,37 @@ package model_test
 
 import (
 	"bufio"
+	"bytes"
+	"fmt"
 	"github.com/aemengo/gswt/model"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
+	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 )
 
 func TestParser(t *testing.T) {
 	spec.Run(t, "Parser", testParser, spec.Report(report.Terminal{}))
 	spec.Run(t, "Parser (stdin)", testParserStdin, spec.Report(report.Terminal{}))
+	spec.Run(t, "Parser (json)", testParserJSON, spec.Report(report.Terminal{}))
+	spec.Run(t, "Parser (plain)", testParserPlain, spec.Report(report.Terminal{}))
+	spec.Run(t, "Parser (parallel)", testParserParallel, spec.Report(report.Terminal{}))
+	spec.Run(t, "Parser (panic)", testParserPanic, spec.Report(report.Terminal{}))
+	spec.Run(t, "Parser (build)", testParserBuild, spec.Report(report.Terminal{}))
+	spec.Run(t, "Parser (packages)", testParserPackages, spec.Report(report.Terminal{}))
+	spec.Run(t, "Parser (race)", testParserRace, spec.Report(report.Terminal{}))
+	spec.Run(t, "Parser (timeout)", testParserTimeout, spec.Report(report.Terminal{}))
+	spec.Run(t, "Parser (fuzz)", testParserFuzz, spec.Report(report.Terminal{}))
+	spec.Run(t, "Parser (benchmarks)", testParserBenchmarks, spec.Report(report.Terminal{}))
+	spec.Run(t, "Parser (formats)", testParserFormats, spec.Report(report.Terminal{}))
+	spec.Run(t, "Parser (ginkgo)", testParserGinkgo, spec.Report(report.Terminal{}))
+	spec.Run(t, "Parser (testify)", testParserTestify, spec.Report(report.Terminal{}))
+	spec.Run(t, "Parser (diffs)", testParserDiffs, spec.Report(report.Terminal{}))
+	spec.Run(t, "Parser (junit)", testParserJUnit, spec.Report(report.Terminal{}))
+	spec.Run(t, "Parser (reruns)", testParserReruns, spec.Report(report.Terminal{}))
+	spec.Run(t, "Parser (stopped)", testParserStopped, spec.Report(report.Terminal{}))
 }
 
 func testParser(t *testing.T, _ spec.G, it spec.S) {
@@ -32,18 +53,20 @@ func testParser(t *testing.T, _ spec.G, it spec.S) {
 
 		assertNoError(t, scanner.Err())
 
-		parser := model.NewParser(nil, nil, nil)
-		parser.ParseGoTestStep(&id, &step)
+		parser := model.NewParser(nil, nil, nil, nil, nil, nil)
+		parser.ParseStep(&id, &step)
 
 		assertNum(t, len(step.TestSuites), 2)
 
 		assertString(t, step.TestSuites[0].Title, "Suite: acceptance-analyzer/0.3 (Passed: 24 | Failed: 2 | Skipped: 7)")
 		assertString(t, step.TestSuites[1].Title, "Suite: acceptance-analyzer/0.4 (Passed: 24 | Failed: 2 | Skipped: 7)")
 
-		assertNum(t, len(step.TestSuites[0].TestRuns), 35)
+		assertNum(t, len(step.TestSuites[0].TestRuns), 13)
+		assertNum(t, len(step.TestSuites[0].AllTestRuns()), 78)
 		assertNum(t, len(step.TestSuites[0].FailedTestRuns()), 4)
 
-		assertNum(t, len(step.TestSuites[1].TestRuns), 35)
+		assertNum(t, len(step.TestSuites[1].TestRuns), 13)
+		assertNum(t, len(step.TestSuites[1].AllTestRuns()), 78)
 		assertNum(t, len(step.TestSuites[1].FailedTestRuns()), 4)
 
 		assertNum(t, len(step.TestSuites[0].TestRuns[0].Lines), 5)
@@ -52,6 +75,37 @@ func testParser(t *testing.T, _ spec.G, it spec.S) {
 		assertNum(t, step.TestSuites[0].TestCount, 33)
 		assertNum(t, step.TestSuites[1].TestCount, 33)
 	})
+
+	it("records how long each test took", func() {
+		step := parseFixture(t, "./parser_test_fixture.txt")
+
+		assertString(t, step.TestSuites[0].TestRuns[0].Elapsed.String(), "2m34.65s")
+		assertString(t, step.TestSuites[0].TestRuns[2].TestRuns[0].Elapsed.String(), "650ms")
+		assertString(t, step.TestSuites[0].Elapsed.String(), "10ms")
+
+		slowestRuns := step.SlowestTestRuns(3)
+		assertNum(t, len(slowestRuns), 3)
+		assertString(t, slowestRuns[0].Name, "TestAnalyzer/acceptance-analyzer/0.3/daemon_case/cache_is_provided/cache_image_case/cache_image_is_in_a_registry/no_auth_registry/restores_cache_metadata")
+		assertString(t, slowestRuns[0].Elapsed.String(), "4.92s")
+		assertString(t, slowestRuns[2].Elapsed.String(), "3.86s")
+	})
+
+	it("nests runs by name", func() {
+		step := parseFixture(t, "./parser_test_fixture.txt")
+
+		suite := step.TestSuites[0]
+		assertString(t, suite.Name, "TestAnalyzer/acceptance-analyzer/0.3")
+
+		registryCase := suite.FailedTestRuns()[3]
+		assertString(t, registryCase.Name, "TestAnalyzer/acceptance-analyzer/0.3/registry_case")
+		assertNum(t, len(registryCase.TestRuns), 4)
+		assertNum(t, registryCase.FailureCount(), 1)
+
+		failedRuns := registryCase.FailedTestRuns()
+		assertNum(t, len(failedRuns), 1)
+		assertString(t, failedRuns[0].Name, "TestAnalyzer/acceptance-analyzer/0.3/registry_case/writes_analyzed.toml")
+		assertNum(t, len(failedRuns[0].Lines), 2)
+	})
 }
 
 func testParserStdin(t *testing.T, _ spec.G, it spec.S) {
@@ -78,8 +132,8 @@ func testParserStdin(t *testing.T, _ spec.G, it spec.S) {
 		assertNoError(t, err)
 		defer f.Close()
 
-		parser := model.NewParser(testSuiteChan, nil, doneChan)
-		go parser.ParseGoTestStdin(f)
+		parser := model.NewParser(testSuiteChan, nil, nil, nil, nil, doneChan)
+		go parser.ParseStdin(f)
 
 		testSuites := collectTestSuites()
 
@@ -88,10 +142,12 @@ func testParserStdin(t *testing.T, _ spec.G, it spec.S) {
 		assertString(t, testSuites[0].Title, "Suite: acceptance-analyzer/0.3 (Passed: 24 | Failed: 2 | Skipped: 7)")
 		assertString(t, testSuites[1].Title, "Suite: acceptance-analyzer/0.4 (Passed: 24 | Failed: 2 | Skipped: 7)")
 
-		assertNum(t, len(testSuites[0].TestRuns), 35)
+		assertNum(t, len(testSuites[0].TestRuns), 13)
+		assertNum(t, len(testSuites[0].AllTestRuns()), 78)
 		assertNum(t, len(testSuites[0].FailedTestRuns()), 4)
 
-		assertNum(t, len(testSuites[1].TestRuns), 35)
+		assertNum(t, len(testSuites[1].TestRuns), 13)
+		assertNum(t, len(testSuites[1].AllTestRuns()), 78)
 		assertNum(t, len(testSuites[1].FailedTestRuns()), 4)
 
 		assertNum(t, len(testSuites[0].TestRuns[0].Lines), 5)
@@ -100,6 +156,1125 @@ func testParserStdin(t *testing.T, _ spec.G, it spec.S) {
 		assertNum(t, testSuites[0].TestCount, 33)
 		assertNum(t, testSuites[1].TestCount, 33)
 	})
+
+	it("reads lines longer than a bufio.Scanner would by default", func() {
+		var (
+			testSuiteChan = make(chan model.TestSuite, 10)
+			doneChan      = make(chan bool, 1)
+			errChan       = make(chan error, 1)
+			output        = "    big_test.go:9: " + strings.Repeat("x", 1024*1024)
+		)
+
+		stdin := strings.Join([]string{
+			`{"Action":"run","Package":"example.com/big","Test":"TestBig"}`,
+			fmt.Sprintf(`{"Action":"output","Package":"example.com/big","Test":"TestBig","Output":"%s\n"}`, output),
+			`{"Action":"fail","Package":"example.com/big","Test":"TestBig","Elapsed":0.01}`,
+			`{"Action":"output","Package":"example.com/big","Output":"FAIL\texample.com/big\t0.010s\n"}`,
+			`{"Action":"fail","Package":"example.com/big","Elapsed":0.01}`,
+		}, "\n")
+
+		parser := model.NewParser(testSuiteChan, nil, nil, nil, nil, doneChan)
+		go func() {
+			errChan <- parser.ParseStdin(strings.NewReader(stdin))
+		}()
+
+		<-doneChan
+		assertNoError(t, <-errChan)
+
+		suite := <-testSuiteChan
+		assertString(t, suite.Package, "example.com/big")
+		assertBool(t, suite.TestRuns[0].Failed(), true)
+		assertNum(t, len(suite.TestRuns[0].Lines), 1)
+		assertString(t, suite.TestRuns[0].Lines[0], output)
+	})
+}
+
+func testParserJSON(t *testing.T, _ spec.G, it spec.S) {
+	it("parses correctly", func() {
+		step := parseFixture(t, "./parser_json_test_fixture.txt")
+
+		assertNum(t, len(step.TestSuites), 2)
+
+		assertString(t, step.TestSuites[0].Title, "Suite: acceptance-analyzer/0.3 (Passed: 24 | Failed: 2 | Skipped: 7)")
+		assertString(t, step.TestSuites[1].Title, "Suite: acceptance-analyzer/0.4 (Passed: 24 | Failed: 2 | Skipped: 7)")
+
+		assertNum(t, len(step.TestSuites[0].TestRuns), 13)
+		assertNum(t, len(step.TestSuites[0].AllTestRuns()), 78)
+		assertNum(t, len(step.TestSuites[0].FailedTestRuns()), 4)
+
+		assertNum(t, len(step.TestSuites[1].TestRuns), 13)
+		assertNum(t, len(step.TestSuites[1].AllTestRuns()), 78)
+		assertNum(t, len(step.TestSuites[1].FailedTestRuns()), 4)
+
+		assertNum(t, len(step.TestSuites[0].TestRuns[0].Lines), 5)
+		assertNum(t, len(step.TestSuites[1].TestRuns[0].Lines), 5)
+
+		assertNum(t, step.TestSuites[0].TestCount, 33)
+		assertNum(t, step.TestSuites[1].TestCount, 33)
+
+		errorsRun := step.TestSuites[0].TestRuns[2].TestRuns[0]
+		assertString(t, errorsRun.Name, "TestAnalyzer/acceptance-analyzer/0.3/called_without_an_app_image/errors")
+		assertString(t, errorsRun.Elapsed.String(), "650ms")
+	})
+}
+
+func testParserPlain(t *testing.T, _ spec.G, it spec.S) {
+	it("parses correctly", func() {
+		step := parseFixture(t, "./parser_plain_test_fixture.txt")
+
+		assertNum(t, len(step.TestSuites), 3)
+
+		assertString(t, step.TestSuites[0].Title, "TestParse (Passed: 2 | Failed: 1 | Skipped: 1)")
+		assertString(t, step.TestSuites[1].Title, "TestFormat (Passed: 1 | Failed: 0 | Skipped: 0)")
+		assertString(t, step.TestSuites[2].Title, "TestRender (Passed: 0 | Failed: 1 | Skipped: 0)")
+
+		assertNum(t, len(step.TestSuites[0].TestRuns), 4)
+		assertNum(t, len(step.TestSuites[0].AllTestRuns()), 6)
+		assertNum(t, len(step.TestSuites[0].FailedTestRuns()), 2)
+		assertNum(t, step.TestSuites[0].TestCount, 4)
+
+		assertString(t, step.TestSuites[0].TestRuns[2].TestRuns[1].Name, "TestParse/nested/bad")
+		assertNum(t, len(step.TestSuites[0].TestRuns[2].TestRuns[1].Lines), 1)
+
+		assertNum(t, len(step.TestSuites[1].TestRuns), 1)
+		assertNum(t, len(step.TestSuites[1].TestRuns[0].Lines), 1)
+		assertNum(t, len(step.TestSuites[1].FailedTestRuns()), 0)
+
+		assertNum(t, len(step.FailedTestSuites()), 2)
+
+		skippedRuns := step.TestSuites[0].SkippedTestRuns()
+		assertNum(t, len(skippedRuns), 1)
+		assertString(t, skippedRuns[0].Name, "TestParse/windows_only")
+		assertString(t, skippedRuns[0].Lines[0], "    widgets_test.go:18: not supported on linux")
+		assertNum(t, model.Logs{step}.SkipCount(), 1)
+	})
+}
+
+func testParserParallel(t *testing.T, _ spec.G, it spec.S) {
+	it("attributes output to the test that printed it", func() {
+		step := parseFixture(t, "./parser_parallel_test_fixture.txt")
+
+		assertNum(t, len(step.TestSuites), 2)
+
+		deploy := step.TestSuites[0]
+		assertString(t, deploy.Title, "TestDeploy (Passed: 2 | Failed: 1 | Skipped: 0)")
+		assertNum(t, len(deploy.TestRuns[0].Lines), 1)
+		assertString(t, deploy.TestRuns[0].Lines[0], "    fleet_test.go:21: all regions scheduled")
+
+		failedRuns := deploy.FailedTestRuns()
+		assertNum(t, len(failedRuns), 2)
+		assertString(t, failedRuns[1].Name, "TestDeploy/eu")
+		assertNum(t, len(failedRuns[1].Lines), 3)
+		assertString(t, failedRuns[1].Lines[2], "    fleet_test.go:17: eu: health check failed")
+
+		assertNum(t, len(deploy.TestRuns[1].Lines), 2)
+		assertNum(t, len(deploy.TestRuns[3].Lines), 2)
+
+		rollback := step.TestSuites[1]
+		assertNum(t, len(rollback.TestRuns[0].Lines), 0)
+		assertNum(t, len(rollback.TestRuns[1].Lines), 1)
+		assertString(t, rollback.TestRuns[1].Lines[0], "        fleet_test.go:40: rollback of eu timed out")
+		assertNum(t, len(rollback.TestRuns[2].Lines), 0)
+	})
+}
+
+func testParserPanic(t *testing.T, _ spec.G, it spec.S) {
+	it("attaches the crash to the test that panicked", func() {
+		step := parseFixture(t, "./parser_panic_test_fixture.txt")
+
+		assertNum(t, len(step.TestSuites), 2)
+		assertNum(t, len(step.FailedTestSuites()), 2)
+
+		// the panic is reported as a failure of the test first
+		balance := step.TestSuites[0]
+		assertString(t, balance.Title, "TestBalance (Passed: 1 | Failed: 1 | Skipped: 0)")
+
+		debits := balance.TestRuns[2]
+		assertString(t, debits.Name, "TestBalance/debits")
+		assertBool(t, debits.Panicked, true)
+		assertNum(t, len(debits.Lines), 16)
+		assertString(t, debits.Lines[1], "panic: runtime error: index out of range [3] with length 0 [recovered, repanicked]")
+		assertString(t, debits.Lines[3], "goroutine 8 [running]:")
+
+		// the panic comes from a goroutine, with no report at all
+		async := step.TestSuites[1]
+		assertString(t, async.Title, "TestAsync (Passed: 0 | Failed: 1 | Skipped: 0)")
+
+		worker := async.TestRuns[1]
+		assertBool(t, worker.Panicked, true)
+		assertBool(t, worker.Status == model.TestFailed, true)
+		assertNum(t, len(worker.Lines), 8)
+		assertString(t, worker.Lines[len(worker.Lines)-1], "\t/home/runner/work/ledger/async/async_test.go:20 +0x45")
+	})
+
+	it("tallies spec suites that never printed their own", func() {
+		var (
+			id   = 1
+			step = model.Step{Lines: fixtureLines(t, "./parser_test_fixture.txt")[:100]}
+		)
+
+		step.Lines = append(step.Lines,
+			"panic: boom",
+			"",
+			"goroutine 9 [running]:",
+			"FAIL\tgithub.com/buildpacks/lifecycle/acceptance\t120.1s",
+		)
+
+		parser := model.NewParser(nil, nil, nil, nil, nil, nil)
+		parser.ParseStep(&id, &step)
+
+		assertNum(t, len(step.TestSuites), 1)
+		assertString(t, step.TestSuites[0].Title, "Suite: acceptance-analyzer/0.3 (Passed: 0 | Failed: 1 | Skipped: 0)")
+		assertNum(t, step.TestSuites[0].TestCount, 33)
+
+		failedRuns := step.TestSuites[0].FailedTestRuns()
+		assertNum(t, len(failedRuns), 1)
+		assertString(t, failedRuns[0].TestRuns[0].Name, "TestAnalyzer/acceptance-analyzer/0.3/called_with_group/errors")
+		assertNum(t, len(failedRuns[0].TestRuns[0].Lines), 4)
+	})
+}
+
+func testParserBuild(t *testing.T, when spec.G, it spec.S) {
+	var step model.Step
+
+	assertBuildFailures := func() {
+		assertNum(t, len(step.BuildFailures), 3)
+		assertNum(t, len(step.FailedTestSuites()), 0)
+		assertBool(t, step.HaveUnhandledFailures(), false)
+
+		ledger := step.BuildFailures[0]
+		assertString(t, ledger.Package, "example.com/ledger")
+		assertNum(t, len(ledger.Lines), 2)
+		assertNum(t, len(ledger.Diagnostics), 2)
+		assertString(t, ledger.Diagnostics[1].File, "./ledger.go")
+		assertNum(t, ledger.Diagnostics[1].Line, 4)
+		assertNum(t, ledger.Diagnostics[1].Column, 19)
+		assertString(t, ledger.Diagnostics[1].Message, "undefined: missing")
+
+		// test files that fail to build are reported for the package
+		audit := step.BuildFailures[1]
+		assertString(t, audit.Package, "example.com/ledger/audit")
+		assertNum(t, len(audit.Diagnostics), 3)
+		assertString(t, audit.Diagnostics[0].File, "audit/audit_test.go")
+		assertString(t, audit.Diagnostics[0].Message, `"fmt" imported and not used`)
+
+		// packages importing a broken one never get compiled themselves
+		reporting := step.BuildFailures[2]
+		assertString(t, reporting.Package, "example.com/ledger/report")
+		assertNum(t, len(reporting.Diagnostics), 0)
+
+		assertNum(t, len(step.TestSuites), 1)
+		assertString(t, step.TestSuites[0].Title, "TestFormat (Passed: 1 | Failed: 0 | Skipped: 0)")
+	}
+
+	when("reading verbose output", func() {
+		it("collects compiler errors per package", func() {
+			step = parseFixture(t, "./parser_build_test_fixture.txt")
+			assertBuildFailures()
+		})
+	})
+
+	when("reading json output", func() {
+		it("collects compiler errors per package", func() {
+			step = parseFixture(t, "./parser_build_json_test_fixture.txt")
+			assertBuildFailures()
+		})
+	})
+}
+
+func testParserPackages(t *testing.T, when spec.G, it spec.S) {
+	it("groups suites by the package that ran them", func() {
+		step := parseFixture(t, "./parser_panic_test_fixture.txt")
+
+		assertNum(t, len(step.Packages), 2)
+		assertString(t, step.Packages[0].Name, "example.com/ledger")
+		assertBool(t, step.Packages[0].Success, false)
+		assertString(t, step.Packages[0].Elapsed.String(), "5ms")
+		assertString(t, step.Packages[1].Name, "example.com/ledger/async")
+
+		assertNum(t, len(step.TestSuites), 2)
+		assertString(t, step.TestSuites[0].Package, "example.com/ledger")
+		assertString(t, step.TestSuites[1].Package, "example.com/ledger/async")
+	})
+
+	it("records cached packages and those that failed to build", func() {
+		step := parseFixture(t, "./parser_build_test_fixture.txt")
+
+		assertNum(t, len(step.Packages), 4)
+		assertString(t, step.Packages[0].Name, "example.com/ledger")
+		assertBool(t, step.Packages[0].Success, false)
+
+		format := step.Packages[2]
+		assertString(t, format.Name, "example.com/ledger/format")
+		assertBool(t, format.Success, true)
+		assertBool(t, format.Cached, true)
+		assertString(t, step.TestSuites[0].Package, "example.com/ledger/format")
+	})
+
+	when("suites arrive after their package", func() {
+		it("still places them in it", func() {
+			step := model.Step{}
+
+			step.AddPackage(model.Package{ID: 5, Name: "example.com/ledger"})
+			step.AddTestSuite(model.TestSuite{ID: 2})
+			step.AddTestSuite(model.TestSuite{ID: 7})
+
+			assertString(t, step.TestSuites[0].Package, "example.com/ledger")
+			assertString(t, step.TestSuites[1].Package, "")
+
+			step.AddPackage(model.Package{ID: 9, Name: "example.com/ledger/async"})
+			assertString(t, step.TestSuites[1].Package, "example.com/ledger/async")
+		})
+	})
+
+	when("summing up the logs", func() {
+		it("counts the failures of every suite", func() {
+			logs := model.Logs{parseFixture(t, "./parser_test_fixture.txt")}
+			logs[0].Success = true
+
+			assertNum(t, logs.FailureCount(), 4)
+			assertBool(t, logs.Failed(), true)
+		})
+
+		it("fails when a package fails to build", func() {
+			logs := model.Logs{parseFixture(t, "./parser_build_test_fixture.txt")}
+			logs[0].Success = true
+
+			assertNum(t, logs.FailureCount(), 0)
+			assertBool(t, logs.Failed(), true)
+		})
+
+		it("passes when everything did", func() {
+			step := model.Step{
+				Success: true,
+				Lines:   []string{"=== RUN   TestFormat", "--- PASS: TestFormat (0.00s)", "PASS", "ok  \texample.com/ledger/format\t0.002s"},
+			}
+
+			var id = 1
+			model.NewParser(nil, nil, nil, nil, nil, nil).ParseStep(&id, &step)
+
+			logs := model.Logs{step}
+			assertNum(t, logs.TestCount(), 1)
+			assertBool(t, logs.Failed(), false)
+		})
+	})
+}
+
+func testParserRace(t *testing.T, when spec.G, it spec.S) {
+	var step model.Step
+
+	assertDataRace := func() {
+		assertNum(t, len(step.TestSuites), 2)
+		assertString(t, step.TestSuites[0].Title, "TestCounter (Passed: 1 | Failed: 1 | Skipped: 0)")
+
+		concurrent := step.TestSuites[0].TestRuns[1]
+		assertString(t, concurrent.Name, "TestCounter/concurrent")
+		assertNum(t, len(concurrent.DataRaces), 1)
+
+		// the report is still part of the test's output
+		assertNum(t, len(concurrent.Lines), 31)
+
+		races := step.DataRaces()
+		assertNum(t, len(races), 1)
+		assertString(t, races[0].Test, "TestCounter/concurrent")
+		assertNum(t, len(races[0].Accesses), 2)
+		assertNum(t, len(races[0].Goroutines), 2)
+
+		read := races[0].Accesses[0]
+		assertString(t, read.Header, "Read at 0x00c0000182f8 by goroutine 9")
+		assertNum(t, len(read.Frames), 2)
+		assertString(t, read.Frames[0].Function, "example.com/counter.(*Counter).Inc()")
+		assertString(t, read.Frames[0].File, "/home/runner/work/counter/counter.go")
+		assertNum(t, read.Frames[0].Line, 5)
+
+		assertString(t, races[0].Accesses[1].Header, "Previous write at 0x00c0000182f8 by goroutine 10")
+		assertString(t, races[0].Goroutines[1].Header, "Goroutine 10 (finished) created at")
+		assertNum(t, len(races[0].Goroutines[1].Frames), 3)
+		assertNum(t, races[0].Goroutines[1].Frames[0].Line, 17)
+
+		assertNum(t, model.Logs{step}.DataRaceCount(), 1)
+	}
+
+	when("reading verbose output", func() {
+		it("pulls out data race reports", func() {
+			step = parseFixture(t, "./parser_race_test_fixture.txt")
+			assertDataRace()
+		})
+	})
+
+	when("reading json output", func() {
+		it("pulls out data race reports", func() {
+			step = parseFixture(t, "./parser_race_json_test_fixture.txt")
+			assertDataRace()
+		})
+	})
+}
+
+func testParserTimeout(t *testing.T, when spec.G, it spec.S) {
+	var step model.Step
+
+	assertTimedOut := func() {
+		assertNum(t, len(step.TestSuites), 1)
+		assertNum(t, len(step.FailedTestSuites()), 1)
+
+		waits := step.TestSuites[0].TestRuns[2]
+		assertString(t, waits.Name, "TestQueue/waits")
+		assertBool(t, waits.Status == model.TestTimedOut, true)
+		assertBool(t, waits.Failed(), true)
+		assertString(t, waits.Elapsed.String(), "2s")
+		assertString(t, waits.Lines[0], "    jobs_test.go:13: waiting on the worker")
+		assertString(t, waits.Lines[1], "panic: test timed out after 2s")
+
+		retries := step.TestSuites[0].TestRuns[3]
+		assertBool(t, retries.Status == model.TestTimedOut, true)
+		assertString(t, retries.Lines[0], "panic: test timed out after 2s")
+		assertString(t, retries.Lines[len(retries.Lines)-1], "\t/usr/local/go/src/testing/testing.go:2258 +0x4d4")
+
+		// the main test was only waiting on its subtests
+		assertBool(t, step.TestSuites[0].TestRuns[0].Status == model.TestRunning, true)
+		assertBool(t, step.TestSuites[0].TestRuns[0].Failed(), false)
+	}
+
+	when("reading verbose output", func() {
+		it("marks the tests that were still running as timed out", func() {
+			step = parseFixture(t, "./parser_timeout_test_fixture.txt")
+			assertTimedOut()
+		})
+	})
+
+	when("reading json output", func() {
+		it("marks the tests that were still running as timed out", func() {
+			step = parseFixture(t, "./parser_timeout_json_test_fixture.txt")
+			assertTimedOut()
+			assertString(t, step.TestSuites[0].Title, "TestQueue (Passed: 1 | Failed: 2 | Skipped: 0)")
+		})
+	})
+}
+
+func testParserFuzz(t *testing.T, when spec.G, it spec.S) {
+	var step model.Step
+
+	assertFailingInput := func() {
+		assertNum(t, len(step.TestSuites), 1)
+		assertString(t, step.TestSuites[0].Title, "FuzzHeader (Passed: 0 | Failed: 1 | Skipped: 0)")
+
+		target := step.TestSuites[0].TestRuns[0]
+		assertString(t, target.Name, "FuzzHeader")
+		assertBool(t, target.Failed(), true)
+		assertString(t, target.Elapsed.String(), "10ms")
+		assertString(t, target.Lines[len(target.Lines)-1], "    go test -run=FuzzHeader/06e2c9db80a08b67")
+
+		input := step.TestSuites[0].TestRuns[1]
+		assertString(t, input.Name, "FuzzHeader/06e2c9db80a08b67")
+		assertBool(t, input.Status == model.TestFailed, true)
+		assertString(t, input.CorpusFile, "testdata/fuzz/FuzzHeader/06e2c9db80a08b67")
+		assertString(t, input.Lines[0], "    Failing input written to testdata/fuzz/FuzzHeader/06e2c9db80a08b67")
+
+		// example.com/parse isn't part of this module
+		assertBool(t, input.CorpusError != nil, true)
+		assertNum(t, len(input.CorpusLines), 0)
+	}
+
+	when("reading verbose output", func() {
+		it("adds the failing input beneath the fuzz test", func() {
+			step = parseFixture(t, "./parser_fuzz_test_fixture.txt")
+			assertFailingInput()
+		})
+	})
+
+	when("reading json output", func() {
+		it("adds the failing input beneath the fuzz test", func() {
+			step = parseFixture(t, "./parser_fuzz_json_test_fixture.txt")
+			assertFailingInput()
+		})
+	})
+
+	when("rerunning the failing input", func() {
+		it("knows which subtests come from the corpus", func() {
+			step = parseFixture(t, "./parser_fuzz_rerun_test_fixture.txt")
+
+			assertNum(t, len(step.TestSuites), 1)
+			assertString(t, step.TestSuites[0].Title, "FuzzHeader (Passed: 2 | Failed: 1 | Skipped: 0)")
+
+			seed := step.TestSuites[0].TestRuns[1]
+			assertString(t, seed.Name, "FuzzHeader/seed#0")
+			assertString(t, seed.CorpusFile, "")
+
+			input := step.TestSuites[0].TestRuns[3]
+			assertString(t, input.Name, "FuzzHeader/06e2c9db80a08b67")
+			assertBool(t, input.Panicked, true)
+			assertString(t, input.CorpusFile, "testdata/fuzz/FuzzHeader/06e2c9db80a08b67")
+		})
+
+		it("reads the failing input from the directory of its package", func() {
+			var (
+				id   = 1
+				step = model.Step{}
+			)
+
+			for _, line := range fixtureLines(t, "./parser_fuzz_rerun_test_fixture.txt") {
+				step.Lines = append(step.Lines, strings.Replace(line, "example.com/parse", "github.com/aemengo/gswt/model", 1))
+			}
+
+			model.DetectFormat(step.Lines).NewParser(model.ParserChans{}).ParseStep(&id, &step)
+
+			input := step.TestSuites[0].TestRuns[3]
+			assertNoError(t, input.CorpusError)
+			assertString(t, strings.Join(input.CorpusLines, "\n"), "go test fuzz v1\nstring(\":\\n\")")
+		})
+	})
+}
+
+func testParserBenchmarks(t *testing.T, when spec.G, it spec.S) {
+	var step model.Step
+
+	assertBenchmarks := func() {
+		assertNum(t, len(step.Benchmarks), 5)
+
+		sum := step.Benchmarks[1]
+		assertString(t, sum.Name, "BenchmarkSum/size=1024")
+		assertString(t, sum.Package, "example.com/hash")
+		assertNum(t, sum.Procs, 1)
+		assertNum(t, len(sum.Samples), 5)
+		assertNum(t, sum.Samples[0].Iterations, 2000)
+		assertString(t, strings.Join(sum.Units(), " "), "ns/op MB/s")
+
+		// a custom metric from b.ReportMetric is listed like the rest
+		hex := step.Benchmarks[2]
+		assertString(t, strings.Join(hex.Units(), " "), "ns/op bytes/op B/op allocs/op")
+		assertNum(t, int(hex.Stat("bytes/op").Mean), 64)
+
+		open := step.Benchmarks[3]
+		assertString(t, open.Name, "BenchmarkOpen")
+		assertString(t, open.Package, "example.com/hash/store")
+		assertBool(t, open.Failed, true)
+		assertNum(t, len(open.Samples), 0)
+		assertString(t, open.Lines[1], "    store_test.go:7: no database running on localhost:5432")
+
+		assertNum(t, len(step.FailedBenchmarks()), 1)
+		assertBool(t, step.HaveUnhandledFailures(), false)
+	}
+
+	when("reading verbose output", func() {
+		it("collects the results of each benchmark", func() {
+			step = parseFixture(t, "./parser_bench_test_fixture.txt")
+			assertBenchmarks()
+
+			sample := step.Benchmarks[0].Samples[0]
+			assertNum(t, len(sample.Metrics), 2)
+			assertString(t, fmt.Sprintf("%v %s", sample.Metrics[0].Value, sample.Metrics[0].Unit), "7.883 ns/op")
+			assertString(t, fmt.Sprintf("%v %s", sample.Metrics[1].Value, sample.Metrics[1].Unit), "2029.81 MB/s")
+		})
+	})
+
+	when("reading json output", func() {
+		it("collects the results of each benchmark, printed in parts", func() {
+			step = parseFixture(t, "./parser_bench_json_test_fixture.txt")
+			assertBenchmarks()
+		})
+	})
+
+	when("comparing to a saved run", func() {
+		it("tells which changes are significant", func() {
+			step = parseFixture(t, "./parser_bench_test_fixture.txt")
+
+			baseline, err := model.BenchmarksFromFile("./parser_bench_baseline_test_fixture.txt")
+			assertNoError(t, err)
+			assertNum(t, len(baseline), 5)
+
+			step.BaselineBenchmarks = baseline
+
+			old, ok := step.BaselineBenchmark(step.Benchmarks[0])
+			assertBool(t, ok, true)
+
+			deltas := step.Benchmarks[0].Compare(old)
+			assertNum(t, len(deltas), 2)
+			assertString(t, deltas[0].Unit, "ns/op")
+			assertString(t, fmt.Sprintf("%.2f%% p=%.3f", deltas[0].Change*100, deltas[0].P), "-40.72% p=0.016")
+			assertBool(t, deltas[0].Significant(), true)
+
+			old, ok = step.BaselineBenchmark(step.Benchmarks[1])
+			assertBool(t, ok, true)
+
+			deltas = step.Benchmarks[1].Compare(old)
+			assertBool(t, deltas[0].Significant(), false)
+
+			// the same numbers every time are never a change
+			old, _ = step.BaselineBenchmark(step.Benchmarks[2])
+			deltas = step.Benchmarks[2].Compare(old)
+			assertString(t, deltas[3].Unit, "allocs/op")
+			assertString(t, fmt.Sprintf("%.3f", deltas[3].P), "1.000")
+		})
+	})
+}
+
+func testParserFormats(t *testing.T, when spec.G, it spec.S) {
+	when("sniffing stdin", func() {
+		it("detects go test output and reads it from the start", func() {
+			contents, err := ioutil.ReadFile("./parser_json_test_fixture.txt")
+			assertNoError(t, err)
+
+			format, stdin := model.SniffFormat(bytes.NewReader(contents))
+			assertString(t, format.Name, "go")
+
+			replayed, err := ioutil.ReadAll(stdin)
+			assertNoError(t, err)
+			assertBool(t, bytes.Equal(replayed, contents), true)
+		})
+
+		it("falls back to go test output when nothing claims it", func() {
+			format, stdin := model.SniffFormat(strings.NewReader("building...\n"))
+			assertString(t, format.Name, "go")
+
+			replayed, err := ioutil.ReadAll(stdin)
+			assertNoError(t, err)
+			assertString(t, string(replayed), "building...\n")
+		})
+	})
+
+	when("a format is registered", func() {
+		it("is tried before the built-in ones", func() {
+			model.RegisterFormat(model.Format{
+				Name: "tap",
+				Detect: func(lines []string) bool {
+					return strings.HasPrefix(lines[0], "TAP version")
+				},
+				NewParser: func(chans model.ParserChans) model.TestParser {
+					return model.NewParser(chans.TestSuites, chans.BuildFailures, chans.Packages, chans.Benchmarks, chans.Lines, chans.Done)
+				},
+			})
+
+			format, _ := model.SniffFormat(strings.NewReader("TAP version 13\nok 1 - parses\n"))
+			assertString(t, format.Name, "tap")
+
+			format, ok := model.LookupFormat("go")
+			assertBool(t, ok, true)
+			assertString(t, format.Name, "go")
+
+			_, ok = model.LookupFormat("xunit")
+			assertBool(t, ok, false)
+		})
+	})
+}
+
+func testParserGinkgo(t *testing.T, when spec.G, it spec.S) {
+	var step model.Step
+
+	assertSuite := func(name string) model.TestSuite {
+		assertNum(t, len(step.TestSuites), 1)
+
+		suite := step.TestSuites[0]
+		assertString(t, suite.Title, "Books Suite (Passed: 3 | Failed: 2 | Skipped: 1)")
+		assertString(t, suite.Name, name)
+		assertNum(t, suite.TestCount, 6)
+		assertString(t, suite.TestRuns[0].Name, name)
+		assertBool(t, suite.TestRuns[0].Status == model.TestFailed, true)
+		return suite
+	}
+
+	it("detects ginkgo output, with or without go test's", func() {
+		assertString(t, model.DetectFormat(fixtureLines(t, "./parser_ginkgo_test_fixture.txt")).Name, "ginkgo")
+		assertString(t, model.DetectFormat(fixtureLines(t, "./parser_ginkgo_failures_test_fixture.txt")).Name, "ginkgo")
+		assertString(t, model.DetectFormat(fixtureLines(t, "./parser_ginkgo_v1_test_fixture.txt")).Name, "ginkgo")
+		assertString(t, model.DetectFormat(fixtureLines(t, "./parser_ginkgo_report_test_fixture.txt")).Name, "ginkgo-report")
+		assertString(t, model.DetectFormat(fixtureLines(t, "./parser_plain_test_fixture.txt")).Name, "go")
+	})
+
+	when("reading verbose output", func() {
+		it("nests each spec under its containers", func() {
+			step = parseFixture(t, "./parser_ginkgo_test_fixture.txt")
+
+			suite := assertSuite("TestBooks")
+			assertNum(t, len(suite.AllTestRuns()), 13)
+			assertNum(t, len(suite.SkippedTestRuns()), 1)
+
+			book := suite.TestRuns[1]
+			assertString(t, book.Name, "TestBooks/Book")
+			assertNum(t, len(book.TestRuns), 3)
+			assertNum(t, book.FailureCount(), 2)
+
+			novel := book.TestRuns[0].TestRuns[0].TestRuns[0]
+			assertString(t, novel.Name, "TestBooks/Book/Categorizing books/with more than 300 pages/should be a novel")
+			assertBool(t, novel.Status == model.TestPassed, true)
+
+			lastName := book.TestRuns[1].TestRuns[0]
+			assertString(t, lastName.Name, "TestBooks/Book/Extracting the author's last name/should correctly identify the last name")
+			assertBool(t, lastName.Status == model.TestFailed, true)
+			assertBool(t, strings.Contains(lastName.Lines[2], "[FAILED] Expected"), true)
+			assertBool(t, strings.Contains(lastName.Lines[len(lastName.Lines)-1], "/home/runner/work/library/books/books_test.go:38"), true)
+
+			panicked := book.TestRuns[2].TestRuns[1]
+			assertString(t, panicked.Name, "TestBooks/Book/Loading from the catalog/should not panic")
+			assertBool(t, panicked.Panicked, true)
+		})
+	})
+
+	when("reading only the failures", func() {
+		it("takes the containers from beneath each result", func() {
+			step = parseFixture(t, "./parser_ginkgo_failures_test_fixture.txt")
+
+			suite := assertSuite("Books Suite")
+			assertString(t, suite.Package, "example.com/library/books")
+
+			// without colors, the containers can't be told apart
+			failed := suite.TestRuns[1].TestRuns[0]
+			assertString(t, failed.Name, "Books Suite/Book Extracting the author's last name/should correctly identify the last name")
+			assertString(t, failed.Lines[len(failed.Lines)-1], "  In [It] at: /home/runner/work/library/books/books_test.go:38 @ 10/18/26 03:47:04.935")
+		})
+	})
+
+	when("reading ginkgo v1 output", func() {
+		it("nests each spec under its containers", func() {
+			step = parseFixture(t, "./parser_ginkgo_v1_test_fixture.txt")
+
+			suite := assertSuite("TestBooks")
+			assertNum(t, len(suite.FailedTestRuns()), 3)
+
+			failed := suite.TestRuns[3].TestRuns[0]
+			assertString(t, failed.Name, "TestBooks/Book Extracting the author's last name/should correctly identify the last name")
+			assertString(t, failed.Elapsed.String(), "1ms")
+			assertString(t, failed.Lines[0], "STEP: splitting the author's name")
+			assertString(t, failed.Lines[len(failed.Lines)-1], "    /home/runner/work/library/books/books_test.go:38")
+
+			skipped := suite.TestRuns[4].TestRuns[0]
+			assertBool(t, skipped.Status == model.TestSkipped, true)
+			assertString(t, skipped.Lines[0], "    the catalog service is not available")
+		})
+	})
+
+	when("reading a json report", func() {
+		it("nests each spec under its containers", func() {
+			step = parseFixture(t, "./parser_ginkgo_report_test_fixture.txt")
+
+			suite := assertSuite("Books Suite")
+			assertNum(t, len(suite.AllTestRuns()), 13)
+
+			failed := suite.TestRuns[1].TestRuns[1].TestRuns[0]
+			assertString(t, failed.Name, "Books Suite/Book/Extracting the author's last name/should correctly identify the last name")
+			assertString(t, failed.Lines[0], "[FAILED] Expected")
+			assertString(t, failed.Lines[len(failed.Lines)-1], "In [It] at: /home/runner/work/library/books/books_test.go:38")
+
+			panicked := suite.TestRuns[1].TestRuns[2].TestRuns[1]
+			assertBool(t, panicked.Panicked, true)
+			assertString(t, panicked.Lines[3], "runtime error: invalid memory address or nil pointer dereference")
+		})
+	})
+}
+
+func testParserTestify(t *testing.T, when spec.G, it spec.S) {
+	var step model.Step
+
+	assertAssertions := func() {
+		assertNum(t, len(step.TestSuites), 4)
+
+		total := step.TestSuites[0].TestRuns[0]
+		assertNum(t, len(total.Assertions), 2)
+		assertString(t, total.Assertions[0].Error, "Not equal:")
+		assertString(t, total.Assertions[0].Expected, "30")
+		assertString(t, total.Assertions[0].Actual, "25")
+		assertString(t, total.Assertions[0].Messages, "total of two items")
+		assertString(t, total.Assertions[0].Trace[0], "/home/runner/work/shop/shop/cart_test.go:18")
+		assertString(t, total.Lines[total.Assertions[1].Line], "        \tError Trace:\t/home/runner/work/shop/shop/cart_test.go:19")
+		assertNum(t, len(total.Assertions[1].Lines), 3)
+		assertString(t, total.Assertions[1].Error, "Should be true")
+
+		items := step.TestSuites[1].TestRuns[0]
+		assertNum(t, len(items.Assertions), 1)
+		assertNum(t, len(items.Assertions[0].Diff), 11)
+		assertString(t, items.Assertions[0].Diff[4], "- Price: (int) 3,")
+		assertString(t, items.Assertions[0].Test, "TestItems")
+
+		remove := step.TestSuites[2].TestRuns[3]
+		assertString(t, remove.Name, "TestCartSuite/TestRemove")
+		assertString(t, remove.Assertions[0].Error, "Received unexpected error:\nitem not in cart")
+		assertString(t, remove.Assertions[0].Test, "TestCartSuite/TestRemove")
+
+		helper := step.TestSuites[3].TestRuns[0]
+		assertNum(t, len(helper.Assertions[0].Trace), 2)
+		assertString(t, helper.Assertions[0].Trace[1], "/home/runner/work/shop/shop/cart_test.go:57")
+	}
+
+	when("reading verbose output", func() {
+		it("turns each assertion block into a record", func() {
+			step = parseFixture(t, "./parser_testify_test_fixture.txt")
+			assertAssertions()
+		})
+	})
+
+	when("reading json output", func() {
+		it("turns each assertion block into a record", func() {
+			step = parseFixture(t, "./parser_testify_json_test_fixture.txt")
+			assertAssertions()
+		})
+	})
+}
+
+func testParserDiffs(t *testing.T, when spec.G, it spec.S) {
+	var step model.Step
+
+	when("reading a cmp.Diff", func() {
+		it("splits it into the expected and actual values", func() {
+			step = parseFixture(t, "./parser_diff_test_fixture.txt")
+
+			runs := step.ComparedTestRuns()
+			assertNum(t, len(runs), 2)
+			assertString(t, runs[0].Name, "TestCmp")
+
+			comparisons := runs[0].Comparisons()
+			assertNum(t, len(comparisons), 1)
+			assertNum(t, len(comparisons[0].Expected), 8)
+			assertString(t, comparisons[0].Expected[0], "cmpx.Item{")
+			assertString(t, comparisons[0].Expected[2], "\tPrice: 3,")
+			assertString(t, comparisons[0].Actual[2], "\tPrice: 4,")
+
+			rows := comparisons[0].SideBySide()
+			assertNum(t, len(rows), 8)
+			assertNum(t, len(rows[5].Expected), 3)
+			assertString(t, rows[5].Expected[1].Text, "d")
+			assertBool(t, rows[5].Expected[1].Changed, true)
+			assertNum(t, len(rows[5].Actual), 5)
+			assertString(t, rows[5].Actual[1].Text, "g")
+			assertString(t, rows[5].Actual[2].Text, "re")
+			assertBool(t, rows[5].Actual[2].Changed, false)
+			assertString(t, rows[5].Actual[3].Text, "en")
+			assertBool(t, rows[5].Actual[0].Changed, false)
+		})
+	})
+
+	when("reading labeled values", func() {
+		it("highlights only what differs", func() {
+			step = parseFixture(t, "./parser_diff_test_fixture.txt")
+
+			comparisons := step.ComparedTestRuns()[1].Comparisons()
+			assertNum(t, len(comparisons), 1)
+			assertString(t, comparisons[0].Expected[0], `"hello, world"`)
+			assertString(t, comparisons[0].Actual[0], `"hello world"`)
+
+			rows := comparisons[0].SideBySide()
+			assertNum(t, len(rows), 1)
+			assertNum(t, len(rows[0].Expected), 3)
+			assertString(t, rows[0].Expected[1].Text, ",")
+			assertBool(t, rows[0].Expected[1].Changed, true)
+			assertNum(t, len(rows[0].Actual), 1)
+			assertBool(t, rows[0].Actual[0].Changed, false)
+		})
+	})
+
+	when("reading a testify assertion", func() {
+		it("compares the dumped values line by line", func() {
+			step = parseFixture(t, "./parser_testify_test_fixture.txt")
+
+			runs := step.ComparedTestRuns()
+			assertNum(t, len(runs), 4)
+			assertString(t, runs[1].Name, "TestItems")
+
+			comparisons := runs[1].Comparisons()
+			assertNum(t, len(comparisons), 1)
+
+			rows := comparisons[0].SideBySide()
+			assertNum(t, len(rows), 6)
+			assertString(t, rows[1].Expected[1].Text, "3")
+			assertString(t, rows[1].Actual[1].Text, "4")
+			assertString(t, rows[4].Expected[3].Text, "d")
+			assertString(t, rows[4].Actual[3].Text, "g")
+		})
+	})
+
+	when("only part of a word differs", func() {
+		it("highlights only the characters that changed", func() {
+			rows := model.Comparison{
+				Expected: []string{`"colour"`},
+				Actual:   []string{`"color"`},
+			}.SideBySide()
+
+			assertNum(t, len(rows), 1)
+			assertNum(t, len(rows[0].Expected), 3)
+			assertString(t, rows[0].Expected[0].Text, `"colo`)
+			assertBool(t, rows[0].Expected[0].Changed, false)
+			assertString(t, rows[0].Expected[1].Text, "u")
+			assertBool(t, rows[0].Expected[1].Changed, true)
+			assertString(t, rows[0].Expected[2].Text, `r"`)
+			assertNum(t, len(rows[0].Actual), 1)
+			assertBool(t, rows[0].Actual[0].Changed, false)
+		})
+	})
+
+	when("reading a gomega failure", func() {
+		it("compares what was expected with what it got", func() {
+			step = parseFixture(t, "./parser_ginkgo_failures_test_fixture.txt")
+
+			runs := step.ComparedTestRuns()
+			assertNum(t, len(runs), 1)
+
+			comparisons := runs[0].Comparisons()
+			assertNum(t, len(comparisons), 1)
+			assertString(t, comparisons[0].Expected[0], "<string>: Hugo")
+			assertString(t, comparisons[0].Actual[0], "<string>: Victor")
+		})
+	})
+}
+
+func testParserJUnit(t *testing.T, when spec.G, it spec.S) {
+	var step model.Step
+
+	it("detects junit reports", func() {
+		assertString(t, model.DetectFormat(fixtureLines(t, "./parser_junit_test_fixture.txt")).Name, "junit")
+		assertString(t, model.DetectFormat(fixtureLines(t, "./parser_junit_pytest_test_fixture.txt")).Name, "junit")
+	})
+
+	when("reading a go-junit-report file", func() {
+		it("turns each testsuite into a package", func() {
+			step = parseFixture(t, "./parser_junit_test_fixture.txt")
+
+			assertNum(t, len(step.Packages), 2)
+			assertString(t, step.Packages[0].Name, "github.com/shop/shop")
+			assertBool(t, step.Packages[0].Success, false)
+			assertString(t, step.Packages[0].Elapsed.String(), "12ms")
+
+			assertNum(t, len(step.TestSuites), 7)
+			assertNum(t, len(step.FailedTestSuites()), 4)
+			assertString(t, step.TestSuites[0].Package, "github.com/shop/shop")
+			assertString(t, step.TestSuites[4].Package, "github.com/shop/shop/discount")
+		})
+
+		it("turns each testcase into a run", func() {
+			step = parseFixture(t, "./parser_junit_test_fixture.txt")
+
+			total := step.TestSuites[1]
+			assertString(t, total.Title, "TestTotal (Passed: 0 | Failed: 1 | Skipped: 0)")
+			assertString(t, total.TestRuns[0].Elapsed.String(), "2ms")
+			assertString(t, total.TestRuns[0].Lines[0], "    cart_test.go:18: ")
+			assertNum(t, len(total.TestRuns[0].Assertions), 1)
+			assertString(t, total.TestRuns[0].Assertions[0].Expected, "30")
+
+			checkout := step.TestSuites[2].TestRuns[0]
+			assertBool(t, checkout.Status == model.TestSkipped, true)
+			assertString(t, checkout.Lines[0], "    cart_test.go:40: needs a payment gateway")
+
+			parse := step.TestSuites[6].TestRuns[0]
+			assertBool(t, parse.Panicked, true)
+			assertString(t, parse.Lines[len(parse.Lines)-1], `parsing "SAVE"`)
+		})
+
+		it("nests subtests under their parents", func() {
+			step = parseFixture(t, "./parser_junit_test_fixture.txt")
+
+			apply := step.TestSuites[4]
+			assertString(t, apply.Title, "TestApply (Passed: 1 | Failed: 1 | Skipped: 0)")
+			assertNum(t, len(apply.TestRuns), 3)
+			assertString(t, apply.TestRuns[2].Name, "TestApply/fixed_amount")
+
+			codes := step.TestSuites[5]
+			assertNum(t, len(codes.AllTestRuns()), 3)
+			assertBool(t, codes.TestRuns[1].Status == model.TestPassed, true)
+			assertBool(t, codes.TestRuns[1].Failed(), true)
+			assertString(t, codes.TestRuns[1].TestRuns[0].Name, "TestCodes/expired/last_year")
+		})
+	})
+
+	when("reading a report from another language", func() {
+		it("nests testcases under their classname", func() {
+			step = parseFixture(t, "./parser_junit_pytest_test_fixture.txt")
+
+			assertNum(t, len(step.Packages), 1)
+			assertString(t, step.Packages[0].Name, "pytest")
+
+			assertNum(t, len(step.TestSuites), 2)
+			assertString(t, step.TestSuites[0].Title, "tests.test_cart (Passed: 1 | Failed: 1 | Skipped: 0)")
+
+			totals := step.TestSuites[0].TestRuns[2]
+			assertString(t, totals.Name, "tests.test_cart/test_total")
+			assertString(t, totals.Lines[1], ">       assert total() == 30")
+			assertString(t, totals.Lines[len(totals.Lines)-1], "computing total")
+
+			assertString(t, step.TestSuites[1].TestRuns[1].Elapsed.String(), "20m4.5s")
+		})
+	})
+
+	when("writing a report", func() {
+		it("has a testcase for each run that reads back the same", func() {
+			var (
+				id     = 1
+				report bytes.Buffer
+				logs   = model.Logs{parseFixture(t, "./parser_testify_test_fixture.txt")}
+			)
+
+			err := logs.WriteJUnit(&report)
+			assertNoError(t, err)
+
+			assertBool(t, strings.Contains(report.String(), `<testsuites tests="7" failures="6" errors="0" skipped="0">`), true)
+			assertBool(t, strings.Contains(report.String(), `<testcase name="TestCartSuite/TestAdd" classname="example.com/shop/shop" time="0.000">`), true)
+
+			id = 1
+			step = model.Step{Lines: strings.Split(report.String(), "\n")}
+			model.DetectFormat(step.Lines).NewParser(model.ParserChans{}).ParseStep(&id, &step)
+
+			assertNum(t, len(step.Packages), 1)
+			assertString(t, step.Packages[0].Name, "example.com/shop/shop")
+			assertNum(t, len(step.TestSuites), len(logs[0].TestSuites))
+
+			for i, suite := range logs[0].TestSuites {
+				assertString(t, step.TestSuites[i].Title, suite.Title)
+				assertNum(t, len(step.TestSuites[i].AllTestRuns()), len(suite.AllTestRuns()))
+			}
+
+			items := step.TestSuites[1].TestRuns[0]
+			assertNum(t, len(items.Lines), len(logs[0].TestSuites[1].TestRuns[0].Lines))
+			assertNum(t, len(items.Assertions[0].Diff), 11)
+		})
+	})
+}
+
+func testParserReruns(t *testing.T, when spec.G, it spec.S) {
+	when("building a -run pattern", func() {
+		it("matches each level of the names exactly", func() {
+			patterns := model.RunPatterns([]string{"TestRefund"})
+			assertNum(t, len(patterns), 1)
+			assertString(t, patterns[0], "^TestRefund$")
+
+			patterns = model.RunPatterns([]string{"TestCheckout/with_discount_(10%)"})
+			assertNum(t, len(patterns), 1)
+			assertString(t, patterns[0], `^TestCheckout$/^with_discount_\(10%\)$`)
+		})
+
+		it("combines the names of the same test only", func() {
+			patterns := model.RunPatterns([]string{"TestCheckout/with_coupon", "TestCheckout/with_discount_(10%)", "TestRefund", "TestSearch"})
+			assertNum(t, len(patterns), 2)
+			assertString(t, patterns[0], `^TestCheckout$/^(with_coupon|with_discount_\(10%\))$`)
+			assertString(t, patterns[1], "^(TestRefund|TestSearch)$")
+
+			patterns = model.RunPatterns([]string{"TestCheckout/empty/by_id", "TestCheckout/full"})
+			assertNum(t, len(patterns), 2)
+			assertString(t, patterns[0], "^TestCheckout$/^empty$/^by_id$")
+			assertString(t, patterns[1], "^TestCheckout$/^full$")
+		})
+	})
+
+	when("rerunning every failed test", func() {
+		it("picks the failed tests without failed tests of their own", func() {
+			logs := model.Logs{parseFixture(t, "./parser_rerun_test_fixture.txt")}
+
+			names := logs.StartRerun(0)
+			assertNum(t, len(names), 3)
+			assertString(t, names[0], "TestCheckout/with_discount_(10%)")
+			assertString(t, names[1], "TestCheckout/with_coupon")
+			assertString(t, names[2], "TestRefund")
+
+			runs := logs[0].TestSuites[0].AllTestRuns()
+			assertString(t, runs[0].Name, "TestCheckout")
+			assertBool(t, runs[0].Rerun == model.Rerunning, true)
+			assertString(t, runs[1].Name, "TestCheckout/empty_cart")
+			assertBool(t, runs[1].Rerun == model.NotRerun, true)
+		})
+
+		it("marks each one fixed or still failing", func() {
+			logs := model.Logs{parseFixture(t, "./parser_rerun_test_fixture.txt")}
+			logs.StartRerun(0)
+
+			fixed, stillFailing := logs.FinishRerun(parseFixture(t, "./parser_rerun_results_test_fixture.txt"))
+			assertNum(t, fixed, 2)
+			assertNum(t, stillFailing, 1)
+
+			runs := logs[0].TestSuites[0].AllTestRuns()
+			assertString(t, runs[2].Name, "TestCheckout/with_discount_(10%)")
+			assertBool(t, runs[2].Rerun == model.RerunFixed, true)
+			assertBool(t, runs[2].Status == model.TestFailed, true)
+			assertNum(t, len(runs[2].Lines), 0)
+			assertString(t, runs[3].Name, "TestCheckout/with_coupon")
+			assertBool(t, runs[3].Rerun == model.RerunStillFailing, true)
+			assertString(t, strings.TrimSpace(runs[3].Lines[0]), "rerun_test.go:16: coupon was not applied")
+
+			refund := logs[0].TestSuites[1].TestRuns[0]
+			assertString(t, refund.Name, "TestRefund")
+			assertBool(t, refund.Rerun == model.RerunFixed, true)
+		})
+	})
+
+	when("rerunning the selected test", func() {
+		it("picks it along with the failed tests under it", func() {
+			logs := model.Logs{parseFixture(t, "./parser_rerun_test_fixture.txt")}
+			checkout := logs[0].TestSuites[0].TestRuns[0]
+
+			names := logs.StartRerun(checkout.ID)
+			assertNum(t, len(names), 1)
+			assertString(t, names[0], "TestCheckout")
+
+			runs := logs[0].TestSuites[0].AllTestRuns()
+			assertBool(t, runs[3].Rerun == model.Rerunning, true)
+			assertBool(t, logs[0].TestSuites[1].TestRuns[0].Rerun == model.NotRerun, true)
+		})
+	})
+}
+
+func testParserStopped(t *testing.T, when spec.G, it spec.S) {
+	var step model.Step
+
+	assertStopped := func() {
+		assertNum(t, len(step.TestSuites), 2)
+		assertNum(t, len(step.FailedTestSuites()), 1)
+		assertString(t, step.TestSuites[1].Title, "TestSync (Passed: 0 | Failed: 1 | Skipped: 0)")
+
+		waits := step.TestSuites[1].TestRuns[1]
+		assertString(t, waits.Name, "TestSync/waits_for_the_queue")
+		assertBool(t, waits.Status == model.TestStopped, true)
+		assertBool(t, waits.Failed(), true)
+		assertString(t, waits.Lines[0], "SIGQUIT: quit")
+
+		// only the goroutines running the test's code, without their addresses
+		lines := strings.Join(waits.Lines, "\n")
+		assertBool(t, strings.Contains(lines, "goroutine 8 gp=0x"), true)
+		assertBool(t, strings.Contains(lines, "\t/home/runner/work/shop/shop/hang/hang_test.go:12 +0x1d\n"), true)
+		assertBool(t, strings.Contains(lines, "[idle]"), false)
+		assertBool(t, strings.Contains(lines, "rflags"), false)
+
+		// the main test was only waiting on its subtest
+		assertBool(t, step.TestSuites[1].TestRuns[0].Status == model.TestRunning, true)
+	}
+
+	when("go test is stopped with SIGQUIT", func() {
+		it("attaches where the running tests were to them", func() {
+			step = parseFixture(t, "./parser_quit_test_fixture.txt")
+			assertStopped()
+		})
+	})
+
+	when("go test -json is stopped with SIGQUIT", func() {
+		it("attaches where the running tests were to them", func() {
+			step = parseFixture(t, "./parser_quit_json_test_fixture.txt")
+			assertStopped()
+		})
+	})
+}
+
+// parseFixture parses the lines of a fixture in the format they're detected as
+func parseFixture(t *testing.T, path string) model.Step {
+	t.Helper()
+
+	var (
+		id   = 1
+		step = model.Step{Lines: fixtureLines(t, path)}
+	)
+
+	model.DetectFormat(step.Lines).NewParser(model.ParserChans{}).ParseStep(&id, &step)
+	return step
+}
+
+func fixtureLines(t *testing.T, path string) []string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	assertNoError(t, err)
+	defer f.Close()
+
+	var lines []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	assertNoError(t, scanner.Err())
+	return lines
 }
 
 func assertNoError(t *testing.T, err error) {
@@ -116,6 +1291,13 @@ func assertNum(t *testing.T, actual, expected int) {
 	}
 }
 
+func assertBool(t *testing.T, actual, expected bool) {
+	t.Helper()
+	if actual != expected {
+		t.Errorf("\nactual: %v\nexpected: %v", actual, expected)
+	}
+}
+
 func assertString(t *testing.T, actual, expected string) {
 	t.Helper()
 	if actual != expected {
@@ -0,0 +1,235 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+)
+
+// RerunStatus is how a failed run did when it was run again
+type RerunStatus int
+
+const (
+	NotRerun RerunStatus = iota
+	Rerunning
+	RerunFixed
+	RerunStillFailing
+)
+
+// RunPatterns are -run patterns for go test that together match each of
+// the given test names exactly. go test matches each level of subtests on
+// its own, so the names are only combined at their last level, with one
+// pattern for each test that they are subtests of.
+func RunPatterns(names []string) []string {
+	var (
+		parents  []string
+		patterns []string
+		last     = map[string][]string{}
+	)
+
+	for _, name := range names {
+		parent, part := "", name
+		if i := strings.LastIndex(name, "/"); i != -1 {
+			parent, part = name[:i], name[i+1:]
+		}
+
+		if _, ok := last[parent]; !ok {
+			parents = append(parents, parent)
+		}
+
+		part = regexp.QuoteMeta(part)
+		if !containsString(last[parent], part) {
+			last[parent] = append(last[parent], part)
+		}
+	}
+
+	for _, parent := range parents {
+		var levels []string
+		if parent != "" {
+			for _, part := range strings.Split(parent, "/") {
+				levels = append(levels, "^"+regexp.QuoteMeta(part)+"$")
+			}
+		}
+
+		if parts := last[parent]; len(parts) == 1 {
+			levels = append(levels, "^"+parts[0]+"$")
+		} else {
+			levels = append(levels, "^("+strings.Join(parts, "|")+")$")
+		}
+
+		patterns = append(patterns, strings.Join(levels, "/"))
+	}
+
+	return patterns
+}
+
+// StartRerun marks the failed runs to be run again as Rerunning, and
+// returns their names: the run with the given id when it failed, or
+// every failed run without failed runs of its own when there is no such run
+func (l Logs) StartRerun(id int) []string {
+	var names []string
+
+	for i := range l {
+		for j := range l[i].TestSuites {
+			suite := &l[i].TestSuites[j]
+
+			for _, run := range suite.AllTestRuns() {
+				if run.ID == id && run.Failed() {
+					return l.markRerunning(suite.Package, []string{run.Name})
+				}
+			}
+		}
+	}
+
+	for i := range l {
+		for j := range l[i].TestSuites {
+			suite := &l[i].TestSuites[j]
+
+			runs := suite.AllTestRuns()
+			for _, run := range runs {
+				if run.Status.failed() && !hasFailedRunUnder(runs, run.Name) && !containsString(names, run.Name) {
+					names = append(names, run.Name)
+					l.markRerunning(suite.Package, []string{run.Name})
+				}
+			}
+		}
+	}
+
+	return names
+}
+
+// FinishRerun merges the results of a run of the tests that StartRerun
+// marked into the logs, taking their output from the step it was parsed
+// into, and returns how many were fixed and how many are still failing.
+// Any other failed run that was run again is merged too.
+func (l Logs) FinishRerun(rerun Step) (int, int) {
+	var fixed, stillFailing int
+
+	results := map[string]TestRun{}
+	for _, suite := range rerun.TestSuites {
+		for _, run := range suite.AllTestRuns() {
+			key := suite.Package + " " + run.Name
+			if _, ok := results[key]; !ok || run.Status.failed() {
+				results[key] = run
+			}
+		}
+	}
+
+	for i := range l {
+		for j := range l[i].TestSuites {
+			suite := &l[i].TestSuites[j]
+			root := TestRun{TestRuns: suite.TestRuns}
+
+			root.walk(func(run *TestRun) {
+				if !run.Failed() {
+					return
+				}
+
+				result, ok := results[suite.Package+" "+run.Name]
+				if !ok || result.Status == TestRunning || result.Status == TestSkipped {
+					if run.Rerun == Rerunning {
+						run.Rerun = NotRerun
+					}
+					return
+				}
+
+				run.Lines = result.Lines
+				run.Elapsed = result.Elapsed
+				run.Panicked = result.Panicked
+				run.CorpusFile = result.CorpusFile
+				run.CorpusLines = result.CorpusLines
+				run.CorpusError = result.CorpusError
+				run.DataRaces = result.DataRaces
+				run.Assertions = result.Assertions
+
+				if result.Failed() {
+					run.Rerun = RerunStillFailing
+				} else {
+					run.Rerun = RerunFixed
+				}
+			})
+		}
+	}
+
+	for _, run := range l.rerunTestRuns() {
+		switch run.Rerun {
+		case RerunFixed:
+			fixed = fixed + 1
+		case RerunStillFailing:
+			stillFailing = stillFailing + 1
+		}
+	}
+
+	return fixed, stillFailing
+}
+
+// markRerunning marks the failed runs of the package with the given
+// names, in every suite that they show up in, along with the failed
+// runs above and below them, which are run again with them
+func (l Logs) markRerunning(pkg string, names []string) []string {
+	for i := range l {
+		for j := range l[i].TestSuites {
+			suite := &l[i].TestSuites[j]
+			if suite.Package != pkg {
+				continue
+			}
+
+			root := TestRun{TestRuns: suite.TestRuns}
+			root.walk(func(run *TestRun) {
+				for _, name := range names {
+					if run.Failed() && (run.Name == name || strings.HasPrefix(run.Name, name+"/") || strings.HasPrefix(name, run.Name+"/")) {
+						run.Rerun = Rerunning
+					}
+				}
+			})
+		}
+	}
+
+	return names
+}
+
+// rerunTestRuns are the runs that were run again without failed runs of
+// their own, looking at the main run of a spec test only once
+func (l Logs) rerunTestRuns() []TestRun {
+	var (
+		tr   []TestRun
+		seen = map[string]bool{}
+	)
+
+	for _, s := range l {
+		for _, suite := range s.TestSuites {
+			runs := suite.AllTestRuns()
+			for _, run := range runs {
+				key := suite.Package + " " + run.Name
+				if seen[key] || run.Rerun == NotRerun || hasFailedRunUnder(runs, run.Name) {
+					continue
+				}
+
+				seen[key] = true
+				tr = append(tr, run)
+			}
+		}
+	}
+
+	return tr
+}
+
+// walk calls fn with every run within this one, parents first
+func (r *TestRun) walk(fn func(run *TestRun)) {
+	for i := range r.TestRuns {
+		fn(&r.TestRuns[i])
+		r.TestRuns[i].walk(fn)
+	}
+}
+
+// hasFailedRunUnder says whether any of the runs that failed is nested
+// under the one with the given name, even when the tree doesn't nest it,
+// like it doesn't for the main run of a suite
+func hasFailedRunUnder(runs []TestRun, name string) bool {
+	for _, run := range runs {
+		if run.Status.failed() && strings.HasPrefix(run.Name, name+"/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"sort"
 	"strings"
 )
 
@@ -10,12 +11,61 @@ type Step struct {
 	Selected bool
 	Success  bool
 
-	Lines      []string
-	TestSuites []TestSuite
+	Lines         []string
+	Packages      []Package
+	TestSuites    []TestSuite
+	BuildFailures []BuildFailure
+	Benchmarks    []Benchmark
+
+	// BaselineBenchmarks are from a saved run, for Benchmarks to be compared to
+	BaselineBenchmarks []Benchmark
 }
 
 func (s *Step) IsTest() bool {
-	return len(s.TestSuites) != 0
+	return len(s.TestSuites) != 0 || len(s.BuildFailures) != 0 || len(s.Benchmarks) != 0
+}
+
+// AddTestSuite adds a suite sent by the parser, which
+// can arrive after the package that it belongs to
+func (s *Step) AddTestSuite(ts TestSuite) {
+	if ts.Package == "" {
+		ts.Package = s.packageOf(ts.ID)
+	}
+
+	s.TestSuites = append(s.TestSuites, ts)
+}
+
+// AddPackage adds a package and claims the suites that came before it,
+// since go test only reports on a package after running all of its tests
+func (s *Step) AddPackage(pkg Package) {
+	for i := range s.TestSuites {
+		if s.TestSuites[i].Package == "" && s.TestSuites[i].ID < pkg.ID {
+			s.TestSuites[i].Package = pkg.Name
+		}
+	}
+
+	s.Packages = append(s.Packages, pkg)
+}
+
+// HasPackage says whether go test reported on the package yet
+func (s *Step) HasPackage(name string) bool {
+	for _, pkg := range s.Packages {
+		if pkg.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Step) packageOf(id int) string {
+	for _, pkg := range s.Packages {
+		if id < pkg.ID {
+			return pkg.Name
+		}
+	}
+
+	return ""
 }
 
 func (s *Step) FailedTestSuites() []TestSuite {
@@ -30,8 +80,97 @@ func (s *Step) FailedTestSuites() []TestSuite {
 	return ts
 }
 
+// SlowestTestRuns are the given number of tests that took the
+// longest, out of every suite. Only tests without subtests count.
+func (s *Step) SlowestTestRuns(count int) []TestRun {
+	var tr []TestRun
+
+	for i := range s.TestSuites {
+		tr = append(tr, s.TestSuites[i].timedTestRuns()...)
+	}
+
+	sort.SliceStable(tr, func(i, j int) bool {
+		return tr[i].Elapsed > tr[j].Elapsed
+	})
+
+	if len(tr) > count {
+		tr = tr[:count]
+	}
+	return tr
+}
+
+// DataRaces are the race detector reports from every test. The main
+// run of a spec test is part of each of its suites, so is only looked at once.
+func (s *Step) DataRaces() []DataRace {
+	var (
+		races []DataRace
+		seen  = map[string]bool{}
+	)
+
+	for _, suite := range s.TestSuites {
+		for _, run := range suite.AllTestRuns() {
+			key := suite.Package + " " + run.Name
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+			races = append(races, run.DataRaces...)
+		}
+	}
+
+	return races
+}
+
+// ComparedTestRuns are the failed tests whose output has the values they
+// compared, looking at the main run of a spec test only once like DataRaces
+func (s *Step) ComparedTestRuns() []TestRun {
+	var (
+		tr   []TestRun
+		seen = map[string]bool{}
+	)
+
+	for _, suite := range s.TestSuites {
+		for _, run := range suite.AllTestRuns() {
+			key := suite.Package + " " + run.Name
+			if seen[key] || !run.Status.failed() || len(run.Comparisons()) == 0 {
+				continue
+			}
+
+			seen[key] = true
+			tr = append(tr, run)
+		}
+	}
+
+	return tr
+}
+
+// BaselineBenchmark finds the benchmark from the saved run that
+// the given one is compared to, which ran with the same GOMAXPROCS
+func (s *Step) BaselineBenchmark(bm Benchmark) (Benchmark, bool) {
+	for _, baseline := range s.BaselineBenchmarks {
+		if baseline.Package == bm.Package && baseline.Name == bm.Name && baseline.Procs == bm.Procs {
+			return baseline, true
+		}
+	}
+
+	return Benchmark{}, false
+}
+
+func (s *Step) FailedBenchmarks() []Benchmark {
+	var bms []Benchmark
+
+	for _, bm := range s.Benchmarks {
+		if bm.Failed {
+			bms = append(bms, bm)
+		}
+	}
+
+	return bms
+}
+
 func (s *Step) HaveUnhandledFailures() bool {
-	return len(s.FailedTestSuites()) == 0 && func() bool {
+	return len(s.FailedTestSuites()) == 0 && len(s.BuildFailures) == 0 && len(s.FailedBenchmarks()) == 0 && func() bool {
 		for _, line := range s.Lines {
 			if strings.HasPrefix(line, "FAIL") {
 				return true
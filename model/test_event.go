@@ -0,0 +1,30 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// TestEvent is a single line of `go test -json` (test2json) output
+type TestEvent struct {
+	Action  string
+	Package string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+func parseTestEvent(line string) (TestEvent, bool) {
+	var event TestEvent
+
+	if !strings.HasPrefix(line, "{") {
+		return event, false
+	}
+
+	err := json.Unmarshal([]byte(line), &event)
+	if err != nil || event.Action == "" {
+		return event, false
+	}
+
+	return event, true
+}
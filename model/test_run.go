@@ -1,10 +1,140 @@
 package model
 
+import "time"
+
+// TestStatus is how far a test run got
+type TestStatus int
+
+const (
+	// TestRunning is the status of a run that has no result yet
+	TestRunning TestStatus = iota
+	TestPassed
+	TestFailed
+	TestSkipped
+
+	// TestTimedOut is the status of a run that was still going
+	// when go test gave up on it, after its -timeout
+	TestTimedOut
+
+	// TestStopped is the status of a run that was still going
+	// when go test was stopped from the UI
+	TestStopped
+)
+
+// failed is true for any status that the run should be looked into for
+func (s TestStatus) failed() bool {
+	return s == TestFailed || s == TestTimedOut || s == TestStopped
+}
+
 type TestRun struct {
 	ID       int
 	Name     string
-	Success  bool
+	Status   TestStatus
 	Selected bool
+	Panicked bool
+	Elapsed  time.Duration
+
+	// CorpusFile is the input that a fuzz test failed on, as go test
+	// names it, relative to the directory of the test's package
+	CorpusFile string
+
+	// CorpusLines are the contents of CorpusFile, read once the test's
+	// package is done, or CorpusError says why they couldn't be
+	CorpusLines []string
+	CorpusError error
+
+	// Rerun is how the run did when it was run again after failing,
+	// which leaves its Status as it was
+	Rerun RerunStatus
+
+	Lines      []string
+	DataRaces  []DataRace
+	Assertions []Assertion
+	TestRuns   []TestRun
+}
+
+// Failed reports whether the run, or any run nested under it, failed
+func (r *TestRun) Failed() bool {
+	if r.Status.failed() {
+		return true
+	}
+
+	for i := range r.TestRuns {
+		if r.TestRuns[i].Failed() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *TestRun) FailedTestRuns() []TestRun {
+	var tr []TestRun
+
+	for _, run := range r.TestRuns {
+		if run.Failed() {
+			tr = append(tr, run)
+		}
+	}
+	return tr
+}
+
+// FailureCount is the number of failures within the run, counting
+// each failure where it happened rather than on every parent
+func (r *TestRun) FailureCount() int {
+	var count int
+
+	for i := range r.TestRuns {
+		count = count + r.TestRuns[i].FailureCount()
+	}
+
+	if count == 0 && r.Status.failed() {
+		return 1
+	}
+
+	return count
+}
+
+// SkippedTestRuns are the runs within this one that were skipped,
+// which never have any runs of their own
+func (r *TestRun) SkippedTestRuns() []TestRun {
+	var tr []TestRun
+
+	for _, run := range r.all() {
+		if run.Status == TestSkipped {
+			tr = append(tr, run)
+		}
+	}
+	return tr
+}
+
+func (r *TestRun) toggle(id int) bool {
+	var found bool
+
+	for i := range r.TestRuns {
+		if r.TestRuns[i].ID == id {
+			r.TestRuns[i].Selected = !r.TestRuns[i].Selected
+			found = true
+			continue
+		}
+
+		if r.TestRuns[i].toggle(id) {
+			found = true
+			continue
+		}
+
+		r.TestRuns[i].Selected = false
+	}
+
+	return found
+}
+
+func (r *TestRun) all() []TestRun {
+	var tr []TestRun
 
-	Lines []string
+	for i := range r.TestRuns {
+		tr = append(tr, r.TestRuns[i])
+		tr = append(tr, r.TestRuns[i].all()...)
+	}
+	return tr
 }
@@ -1,11 +1,20 @@
 package model
 
+import (
+	"strings"
+	"time"
+)
+
 type TestSuite struct {
 	ID        int
 	Title     string
 	Selected  bool
 	TestCount int
+	Elapsed   time.Duration
 
+	// Name is the go test name that the suite's runs are nested under
+	Name     string
+	Package  string
 	TestRuns []TestRun
 }
 
@@ -13,9 +22,91 @@ func (s *TestSuite) FailedTestRuns() []TestRun {
 	var tr []TestRun
 
 	for _, run := range s.TestRuns {
-		if !run.Success {
+		if run.Failed() {
 			tr = append(tr, run)
 		}
 	}
 	return tr
 }
+
+// AllTestRuns flattens the suite's tree of runs, parents first
+func (s *TestSuite) AllTestRuns() []TestRun {
+	root := TestRun{TestRuns: s.TestRuns}
+	return root.all()
+}
+
+func (s *TestSuite) SkippedTestRuns() []TestRun {
+	root := TestRun{TestRuns: s.TestRuns}
+	return root.SkippedTestRuns()
+}
+
+// timedTestRuns are the runs without runs of their own, whose time isn't
+// the sum of others', leaving out the ones the suite is nested under
+func (s *TestSuite) timedTestRuns() []TestRun {
+	var tr []TestRun
+
+	for _, run := range s.AllTestRuns() {
+		if len(run.TestRuns) != 0 || run.Name == s.Name || strings.HasPrefix(s.Name, run.Name+"/") {
+			continue
+		}
+
+		tr = append(tr, run)
+	}
+	return tr
+}
+
+// runningTestRuns are the runs without a result, whose runs all
+// have one, leaving out the ones the suite is nested under
+func (s *TestSuite) runningTestRuns() []TestRun {
+	var (
+		tr   []TestRun
+		runs = s.AllTestRuns()
+	)
+
+	for _, run := range runs {
+		if run.Status != TestRunning || run.Name == s.Name || strings.HasPrefix(s.Name, run.Name+"/") {
+			continue
+		}
+
+		var childRunning bool
+		for _, other := range runs {
+			if strings.HasPrefix(other.Name, run.Name+"/") && other.Status == TestRunning {
+				childRunning = true
+			}
+		}
+
+		if !childRunning {
+			tr = append(tr, run)
+		}
+	}
+	return tr
+}
+
+func (s *TestSuite) toggleTestRuns(id int) bool {
+	root := TestRun{TestRuns: s.TestRuns}
+	return root.toggle(id)
+}
+
+// testRunAt returns the run found by following
+// the given indexes down through the tree
+func (s *TestSuite) testRunAt(path []int) *TestRun {
+	run := &s.TestRuns[path[0]]
+
+	for _, i := range path[1:] {
+		run = &run.TestRuns[i]
+	}
+	return run
+}
+
+// addTestRun nests the run under the one at the given path, or at
+// the top of the suite when the path is empty, and returns its path
+func (s *TestSuite) addTestRun(path []int, run TestRun) []int {
+	runs := &s.TestRuns
+	if len(path) != 0 {
+		runs = &s.testRunAt(path).TestRuns
+	}
+
+	*runs = append(*runs, run)
+
+	return append(append([]int{}, path...), len(*runs)-1)
+}
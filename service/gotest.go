@@ -0,0 +1,252 @@
+package service
+
+import (
+	"io"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// GoTest runs go test as a child process, for its output to be parsed
+// as it is written. Its stderr is kept apart from the output.
+type GoTest struct {
+	args   []string
+	stderr io.Writer
+	cmd    *exec.Cmd
+	done   chan bool
+
+	exitCode int
+}
+
+// NewGoTest runs go test with the given arguments, in verbose mode
+// unless -json output is asked for, writing its stderr to stderr
+func NewGoTest(args []string, stderr io.Writer) *GoTest {
+	return &GoTest{
+		args:   goTestArgs(args),
+		stderr: stderr,
+	}
+}
+
+// Args are what go test is run with
+func (g *GoTest) Args() []string {
+	return g.args
+}
+
+// Rerun is go test with the same arguments, except
+// for running only the tests that match the -run pattern
+func (g *GoTest) Rerun(pattern string) *GoTest {
+	return &GoTest{
+		args:   append([]string{"-run", pattern}, withoutRunArgs(g.args)...),
+		stderr: g.stderr,
+	}
+}
+
+// Packages are the package patterns that go test is run with,
+// which is the package in the current directory when there are none
+func (g *GoTest) Packages() []string {
+	_, packages, _ := splitGoTestArgs(g.args)
+	if len(packages) == 0 {
+		return []string{"."}
+	}
+
+	return packages
+}
+
+// ForPackages is go test with the same flags, run for the given packages only
+func (g *GoTest) ForPackages(packages []string) *GoTest {
+	flags, _, testArgs := splitGoTestArgs(g.args)
+
+	args := append(append([]string{}, flags...), packages...)
+	return &GoTest{
+		args:   append(args, testArgs...),
+		stderr: g.stderr,
+	}
+}
+
+// Start runs go test and returns its stdout,
+// which must be read to the end before calling Wait
+func (g *GoTest) Start() (io.Reader, error) {
+	g.cmd = exec.Command("go", append([]string{"test"}, g.args...)...)
+	g.cmd.Stderr = g.stderr
+	g.done = make(chan bool)
+
+	// the test binaries that go test starts are signalled along with it
+	setProcessGroup(g.cmd)
+
+	stdout, err := g.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	err = g.cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	return stdout, nil
+}
+
+// Wait waits for go test to exit and returns its exit code
+func (g *GoTest) Wait() int {
+	// a failing go test exits non-zero, which is what the exit code is for
+	_ = g.cmd.Wait()
+
+	g.exitCode = g.cmd.ProcessState.ExitCode()
+	if g.exitCode == -1 {
+		// it was killed
+		g.exitCode = 1
+	}
+
+	close(g.done)
+	return g.exitCode
+}
+
+// Stop kills go test if it is still running, and returns its
+// exit code once Wait has seen it exit
+func (g *GoTest) Stop() int {
+	if g.cmd == nil || g.cmd.Process == nil {
+		return 0
+	}
+
+	select {
+	case <-g.done:
+	default:
+		_ = signalProcessGroup(g.cmd.Process, syscall.SIGKILL)
+		<-g.done
+	}
+
+	return g.exitCode
+}
+
+// Running reports whether go test was started and hasn't exited yet
+func (g *GoTest) Running() bool {
+	if g.cmd == nil || g.cmd.Process == nil {
+		return false
+	}
+
+	select {
+	case <-g.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Quit sends go test a SIGQUIT, for its tests to print the stack of each
+// of their goroutines, then kills it if it is still running after grace
+func (g *GoTest) Quit(grace time.Duration) {
+	if !g.Running() {
+		return
+	}
+
+	_ = signalProcessGroup(g.cmd.Process, syscall.SIGQUIT)
+
+	go func() {
+		select {
+		case <-g.done:
+		case <-time.After(grace):
+			_ = signalProcessGroup(g.cmd.Process, syscall.SIGKILL)
+		}
+	}()
+}
+
+// Interrupt passes on an interrupt from the terminal to go test,
+// which doesn't get it from there since it has a process group of its own
+func (g *GoTest) Interrupt() {
+	if g.Running() {
+		_ = signalProcessGroup(g.cmd.Process, syscall.SIGINT)
+	}
+}
+
+// goTestArgs adds -v to the arguments, unless they
+// already choose verbose or json output
+func goTestArgs(args []string) []string {
+	flags, _, _ := splitGoTestArgs(args)
+
+	for i := 0; i < len(flags); i++ {
+		parts := strings.SplitN(flags[i], "=", 2)
+
+		switch name := strings.TrimPrefix(strings.TrimPrefix(parts[0], "-"), "-"); {
+		case name == "v" || name == "json":
+			return args
+		case len(parts) == 1 && goTestValueFlags[name]:
+			// its value is the next argument
+			i = i + 1
+		}
+	}
+
+	return append([]string{"-v"}, args...)
+}
+
+// withoutRunArgs drops any -run flag from the arguments,
+// leaving alone the ones after -args that go to the test binary
+func withoutRunArgs(args []string) []string {
+	var result []string
+
+	for i := 0; i < len(args); i++ {
+		var (
+			parts = strings.SplitN(args[i], "=", 2)
+			name  = strings.TrimPrefix(strings.TrimPrefix(parts[0], "-"), "-")
+		)
+
+		switch {
+		case !strings.HasPrefix(args[i], "-"):
+		case name == "args":
+			return append(result, args[i:]...)
+		case name == "run" || name == "test.run":
+			if len(parts) == 1 {
+				i = i + 1
+			}
+			continue
+		case len(parts) == 1 && goTestValueFlags[name] && i+1 < len(args):
+			// its value is the next argument, even when it reads like a flag
+			result = append(result, args[i], args[i+1])
+			i = i + 1
+			continue
+		}
+
+		result = append(result, args[i])
+	}
+
+	return result
+}
+
+// goTestValueFlags are the flags of go test and go build
+// that take a value, which can be given as the next argument
+var goTestValueFlags = map[string]bool{
+	"asmflags": true, "bench": true, "benchtime": true, "blockprofile": true,
+	"blockprofilerate": true, "count": true, "coverpkg": true, "covermode": true,
+	"coverprofile": true, "cpu": true, "cpuprofile": true, "exec": true,
+	"fuzz": true, "fuzzminimizetime": true, "fuzztime": true, "gccgoflags": true,
+	"gcflags": true, "installsuffix": true, "ldflags": true, "list": true,
+	"memprofile": true, "memprofilerate": true, "mod": true, "modfile": true,
+	"mutexprofile": true, "mutexprofilefraction": true, "o": true, "outputdir": true,
+	"overlay": true, "p": true, "parallel": true, "pkgdir": true, "run": true,
+	"shuffle": true, "skip": true, "tags": true, "timeout": true, "toolexec": true,
+	"trace": true, "vet": true,
+}
+
+// splitGoTestArgs tells the flags of go test apart from its package
+// patterns, and from the arguments from -args on that go to the test binary
+func splitGoTestArgs(args []string) ([]string, []string, []string) {
+	var flags, packages []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		switch {
+		case arg == "-args" || arg == "--args":
+			return flags, packages, args[i:]
+		case !strings.HasPrefix(arg, "-"):
+			packages = append(packages, arg)
+		case !strings.Contains(arg, "=") && goTestValueFlags[strings.TrimLeft(arg, "-")] && i+1 < len(args):
+			flags = append(flags, arg, args[i+1])
+			i = i + 1
+		default:
+			flags = append(flags, arg)
+		}
+	}
+
+	return flags, packages, nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGoTestArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"adds -v", []string{"./..."}, []string{"-v", "./..."}},
+		{"adds -v without arguments", nil, []string{"-v"}},
+		{"keeps -v", []string{"-v", "./..."}, []string{"-v", "./..."}},
+		{"keeps -json", []string{"./...", "-json"}, []string{"./...", "-json"}},
+		{"keeps --json", []string{"--json", "./..."}, []string{"--json", "./..."}},
+		{"keeps -json=true", []string{"-json=true", "./..."}, []string{"-json=true", "./..."}},
+		{"adds -v with -run", []string{"-run", "TestX", "./..."}, []string{"-v", "-run", "TestX", "./..."}},
+		{"ignores -json for the test binary", []string{"./...", "-args", "-json"}, []string{"-v", "./...", "-args", "-json"}},
+		{"ignores -v for the test binary", []string{"./...", "--args", "-v"}, []string{"-v", "./...", "--args", "-v"}},
+		{"ignores the value of -run", []string{"-run", "json", "./..."}, []string{"-v", "-run", "json", "./..."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := goTestArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("\nactual: %q\nexpected: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithoutRunArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"without -run", []string{"-v", "./..."}, []string{"-v", "./..."}},
+		{"-run X", []string{"-v", "-run", "TestX", "./..."}, []string{"-v", "./..."}},
+		{"-run=X", []string{"-v", "-run=TestX", "./..."}, []string{"-v", "./..."}},
+		{"--run X", []string{"--run", "TestX", "./..."}, []string{"./..."}},
+		{"--run=X", []string{"-json", "--run=TestX"}, []string{"-json"}},
+		{"-test.run=X", []string{"-test.run=TestX", "./..."}, []string{"./..."}},
+		{"more than one -run", []string{"-run", "TestX", "-run=TestY", "./..."}, []string{"./..."}},
+		{"-run at the end", []string{"./...", "-run"}, []string{"./..."}},
+		{"-run for the test binary", []string{"./...", "-args", "-run", "TestX"}, []string{"./...", "-args", "-run", "TestX"}},
+		{"-run for the test binary after --args", []string{"-run=TestY", "./...", "--args", "-run=TestX"}, []string{"./...", "--args", "-run=TestX"}},
+		{"-runx isn't -run", []string{"-runx", "./..."}, []string{"-runx", "./..."}},
+		{"a value that reads like -run", []string{"-bench", "-run", "./..."}, []string{"-bench", "-run", "./..."}},
+		{"a package named run", []string{"-v", "run"}, []string{"-v", "run"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withoutRunArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("\nactual: %q\nexpected: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitGoTestArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantFlags    []string
+		wantPackages []string
+		wantTestArgs []string
+	}{
+		{"nothing", nil, nil, nil, nil},
+		{"packages", []string{"./a", "./b/..."}, nil, []string{"./a", "./b/..."}, nil},
+		{"boolean flags", []string{"-v", "-json", "./a"}, []string{"-v", "-json"}, []string{"./a"}, nil},
+		{"-run X", []string{"-run", "TestX", "./a"}, []string{"-run", "TestX"}, []string{"./a"}, nil},
+		{"-run=X", []string{"-run=TestX", "./a"}, []string{"-run=TestX"}, []string{"./a"}, nil},
+		{"--run X", []string{"--run", "TestX", "./a"}, []string{"--run", "TestX"}, []string{"./a"}, nil},
+		{"flags after packages", []string{"./a", "-count", "1", "-json"}, []string{"-count", "1", "-json"}, []string{"./a"}, nil},
+		{"-run at the end", []string{"./a", "-run"}, []string{"-run"}, []string{"./a"}, nil},
+		{"-args", []string{"-v", "./a", "-args", "-update", "./golden"}, []string{"-v"}, []string{"./a"}, []string{"-args", "-update", "./golden"}},
+		{"--args", []string{"./a", "--args", "-run", "X"}, nil, []string{"./a"}, []string{"--args", "-run", "X"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags, packages, testArgs := splitGoTestArgs(tt.args)
+
+			if !reflect.DeepEqual(flags, tt.wantFlags) {
+				t.Errorf("flags\nactual: %q\nexpected: %q", flags, tt.wantFlags)
+			}
+
+			if !reflect.DeepEqual(packages, tt.wantPackages) {
+				t.Errorf("packages\nactual: %q\nexpected: %q", packages, tt.wantPackages)
+			}
+
+			if !reflect.DeepEqual(testArgs, tt.wantTestArgs) {
+				t.Errorf("test binary arguments\nactual: %q\nexpected: %q", testArgs, tt.wantTestArgs)
+			}
+		})
+	}
+}
+
+func TestGoTestRerun(t *testing.T) {
+	goTest := NewGoTest([]string{"-run", "TestX", "./a", "-args", "-update"}, nil)
+
+	want := []string{"-run", "^TestY$", "-v", "./a", "-args", "-update"}
+	if got := goTest.Rerun("^TestY$").Args(); !reflect.DeepEqual(got, want) {
+		t.Errorf("\nactual: %q\nexpected: %q", got, want)
+	}
+
+	want = []string{"-v", "-run", "TestX", "./b", "-args", "-update"}
+	if got := goTest.ForPackages([]string{"./b"}).Args(); !reflect.DeepEqual(got, want) {
+		t.Errorf("\nactual: %q\nexpected: %q", got, want)
+	}
+}
@@ -0,0 +1,15 @@
+package service
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// signalProcessGroup kills the process, since windows
+// has no signals to send that it could handle
+func signalProcessGroup(process *os.Process, sig syscall.Signal) error {
+	return process.Kill()
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Change is a save to the watched files, with the packages
+// whose tests have to be run again because of it
+type Change struct {
+	Files    []string
+	Packages []string
+}
+
+// Watcher polls the .go files of the packages that go test runs, and of
+// the packages of the module that they import, for changes to them
+type Watcher struct {
+	goTest   *GoTest
+	logger   *log.Logger
+	interval time.Duration
+	packages []goPackage
+	modTimes map[string]time.Time
+
+	ChangeChan chan Change
+}
+
+// goPackage is what go list tells about a package
+type goPackage struct {
+	importPath string
+	dir        string
+	inModule   bool
+	deps       []string
+}
+
+// NewWatcher starts polling for changes that affect the packages
+// of goTest, every interval
+func NewWatcher(logger *log.Logger, goTest *GoTest, interval time.Duration) *Watcher {
+	w := &Watcher{
+		goTest:     goTest,
+		logger:     logger,
+		interval:   interval,
+		ChangeChan: make(chan Change, 1),
+	}
+	go w.poll()
+	return w
+}
+
+func (w *Watcher) poll() {
+	w.listPackages()
+	w.modTimes = w.scan()
+
+	for {
+		time.Sleep(w.interval)
+
+		if len(w.packages) == 0 {
+			w.listPackages()
+		}
+
+		modTimes := w.scan()
+		files := changedFiles(w.modTimes, modTimes)
+		w.modTimes = modTimes
+
+		if len(files) == 0 {
+			continue
+		}
+
+		// the change may have added imports or packages
+		w.listPackages()
+		w.modTimes = w.scan()
+
+		packages := w.affectedPackages(files)
+		if len(packages) == 0 {
+			w.logger.Printf("no tests to run for changes to %s", strings.Join(files, ", "))
+			continue
+		}
+
+		w.ChangeChan <- Change{Files: files, Packages: packages}
+	}
+}
+
+// listPackages asks go list for the packages of go test along with
+// all that they import, keeping what was known when it fails
+func (w *Watcher) listPackages() {
+	args := append([]string{"list", "-e", "-deps", "-test", "-f", "{{.ImportPath}}\t{{.Dir}}\t{{if .Module}}{{.Module.Main}}{{end}}\t{{join .Deps \" \"}}"}, w.goTest.Packages()...)
+
+	output, err := exec.Command("go", args...).Output()
+	if err != nil {
+		w.logger.Printf("failed to list packages to watch: %s", err)
+		return
+	}
+
+	var (
+		packages []goPackage
+		scanner  = bufio.NewScanner(bytes.NewReader(output))
+	)
+
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 4 {
+			continue
+		}
+
+		packages = append(packages, goPackage{
+			importPath: fields[0],
+			dir:        fields[1],
+			inModule:   fields[2] == "true",
+			deps:       strings.Fields(fields[3]),
+		})
+	}
+
+	w.packages = packages
+}
+
+// scan finds the modification time of each .go file in the
+// directories of the module's packages
+func (w *Watcher) scan() map[string]time.Time {
+	modTimes := map[string]time.Time{}
+
+	for _, pkg := range w.packages {
+		if !pkg.inModule || pkg.dir == "" {
+			continue
+		}
+
+		infos, err := ioutil.ReadDir(pkg.dir)
+		if err != nil {
+			continue
+		}
+
+		for _, info := range infos {
+			if !info.IsDir() && strings.HasSuffix(info.Name(), ".go") {
+				modTimes[filepath.Join(pkg.dir, info.Name())] = info.ModTime()
+			}
+		}
+	}
+
+	return modTimes
+}
+
+// affectedPackages are the packages with tests that are in,
+// or import, the packages of the files that changed
+func (w *Watcher) affectedPackages(files []string) []string {
+	var (
+		packages []string
+		dirs     = map[string]string{}
+		changed  = map[string]bool{}
+	)
+
+	for _, pkg := range w.packages {
+		dirs[packageName(pkg.importPath)] = pkg.dir
+	}
+
+	for _, file := range files {
+		changed[filepath.Dir(file)] = true
+	}
+
+	for _, pkg := range w.packages {
+		// go list names the test binary of each package with tests "<package>.test"
+		if !strings.HasSuffix(pkg.importPath, ".test") {
+			continue
+		}
+
+		name := strings.TrimSuffix(pkg.importPath, ".test")
+		affected := changed[dirs[name]]
+
+		for _, dep := range pkg.deps {
+			if changed[dirs[packageName(dep)]] {
+				affected = true
+			}
+		}
+
+		if affected && !containsString(packages, name) {
+			packages = append(packages, name)
+		}
+	}
+
+	sort.Strings(packages)
+	return packages
+}
+
+// packageName drops the test binary that go list adds
+// to the name of a package built for tests, like "pkg [pkg.test]"
+func packageName(importPath string) string {
+	return strings.SplitN(importPath, " ", 2)[0]
+}
+
+// changedFiles are the files that were added, saved or removed
+func changedFiles(before map[string]time.Time, after map[string]time.Time) []string {
+	var files []string
+
+	for file, modTime := range after {
+		if prev, ok := before[file]; !ok || !prev.Equal(modTime) {
+			files = append(files, file)
+		}
+	}
+
+	for file := range before {
+		if _, ok := after[file]; !ok {
+			files = append(files, file)
+		}
+	}
+
+	sort.Strings(files)
+	return files
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
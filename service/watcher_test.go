@@ -0,0 +1,131 @@
+package service
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatcherAffectedPackages(t *testing.T) {
+	dir := testModule(t, map[string]string{
+		"go.mod":                "module example.com/shop\n\ngo 1.16\n",
+		"money/money.go":        "package money\n\nfunc Cents(n int) int { return n * 100 }\n",
+		"money/money_test.go":   "package money\n\nimport \"testing\"\n\nfunc TestCents(t *testing.T) {}\n",
+		"cart/cart.go":          "package cart\n\nimport \"example.com/shop/money\"\n\nfunc Total(n int) int { return money.Cents(n) }\n",
+		"cart/cart_test.go":     "package cart\n\nimport (\n\t\"testing\"\n\n\t\"example.com/shop/fixtures\"\n)\n\nfunc TestTotal(t *testing.T) { _ = fixtures.Items }\n",
+		"fixtures/fixtures.go":  "package fixtures\n\nvar Items = 3\n",
+		"report/report.go":      "package report\n\nfunc Title() string { return \"report\" }\n",
+		"receipt/receipt.go":    "package receipt\n",
+		"receipt/print_test.go": "package receipt_test\n\nimport (\n\t\"testing\"\n\n\t\"example.com/shop/report\"\n)\n\nfunc TestPrint(t *testing.T) { _ = report.Title() }\n",
+	})
+
+	w := &Watcher{
+		goTest: NewGoTest([]string{"./..."}, nil),
+		logger: log.New(ioutil.Discard, "", 0),
+	}
+
+	w.listPackages()
+	if len(w.packages) == 0 {
+		t.Fatal("go list found no packages")
+	}
+
+	tests := []struct {
+		name string
+		file string
+		want []string
+	}{
+		{"a package with tests", "money/money.go", []string{"example.com/shop/cart", "example.com/shop/money"}},
+		{"a test file", "cart/cart_test.go", []string{"example.com/shop/cart"}},
+		{"a package that only tests import", "fixtures/fixtures.go", []string{"example.com/shop/cart"}},
+		{"a package that only external tests import", "report/report.go", []string{"example.com/shop/receipt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := w.scan()
+			touch(t, filepath.Join(dir, tt.file))
+			after := w.scan()
+
+			files := changedFiles(before, after)
+			if want := []string{filepath.Join(dir, tt.file)}; !reflect.DeepEqual(files, want) {
+				t.Fatalf("changed files\nactual: %q\nexpected: %q", files, want)
+			}
+
+			if got := w.affectedPackages(files); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("\nactual: %q\nexpected: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	var (
+		now    = time.Now()
+		before = map[string]time.Time{"/a.go": now, "/b.go": now, "/c.go": now}
+		after  = map[string]time.Time{"/a.go": now, "/b.go": now.Add(time.Second), "/d.go": now}
+	)
+
+	want := []string{"/b.go", "/c.go", "/d.go"}
+	if got := changedFiles(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("\nactual: %q\nexpected: %q", got, want)
+	}
+
+	if got := changedFiles(after, after); len(got) != 0 {
+		t.Errorf("\nactual: %q\nexpected: none", got)
+	}
+}
+
+// testModule writes the files of a module to a temporary directory,
+// which is the working directory for the rest of the test, like it is
+// for go-swt, and returns it
+func testModule(t *testing.T, files map[string]string) string {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, contents := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		os.Chdir(wd)
+	})
+
+	return dir
+}
+
+// touch saves the file without changing it, a second later
+// than it was saved before so that the change can be seen
+func touch(t *testing.T, path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	modTime := info.ModTime().Add(time.Second)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 )
 
 func LogsDir(homeDir string) string {
@@ -52,4 +53,35 @@ func ShowFileInEditor(path string) error {
 	command := exec.Command(binaryPath, path)
 	command.Stdin, command.Stdout, command.Stderr = os.Stdin, os.Stdout, os.Stderr
 	return command.Run()
+}
+
+// ShowSourceInEditor opens the file at the line in $EDITOR, which
+// can have arguments of its own, like "code --wait"
+func ShowSourceInEditor(path string, line int) error {
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		return fmt.Errorf("unable to find path to $EDITOR: it isn't set")
+	}
+
+	binaryPath, err := exec.LookPath(editor[0])
+	if err != nil {
+		return fmt.Errorf("unable to find path to $EDITOR: %s", err)
+	}
+
+	command := exec.Command(binaryPath, append(editor[1:], editorLineArgs(editor[0], path, line)...)...)
+	command.Stdin, command.Stdout, command.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return command.Run()
+}
+
+// editorLineArgs open the file at the line in the syntax of the editor,
+// which is "+57 path" for vim, emacs, nano and most others
+func editorLineArgs(editor string, path string, line int) []string {
+	name := filepath.Base(editor)
+
+	switch strings.TrimSuffix(name, filepath.Ext(name)) {
+	case "code", "code-insiders", "codium":
+		return []string{"--goto", fmt.Sprintf("%s:%d", path, line)}
+	default:
+		return []string{fmt.Sprintf("+%d", line), path}
+	}
 }
\ No newline at end of file
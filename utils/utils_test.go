@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEditorLineArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		editor string
+		want   []string
+	}{
+		{"vim", "vim", []string{"+57", "cart_test.go"}},
+		{"nvim", "nvim", []string{"+57", "cart_test.go"}},
+		{"emacs", "emacs", []string{"+57", "cart_test.go"}},
+		{"vim by path", "/usr/bin/vim", []string{"+57", "cart_test.go"}},
+		{"code", "code", []string{"--goto", "cart_test.go:57"}},
+		{"code-insiders", "code-insiders", []string{"--goto", "cart_test.go:57"}},
+		{"codium", "codium", []string{"--goto", "cart_test.go:57"}},
+		{"code by path", "/usr/local/bin/code", []string{"--goto", "cart_test.go:57"}},
+		{"code.exe", "code.exe", []string{"--goto", "cart_test.go:57"}},
+		{"unknown editor", "micro", []string{"+57", "cart_test.go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := editorLineArgs(tt.editor, "cart_test.go", 57); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("\nactual: %q\nexpected: %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -11,8 +11,25 @@ const (
 	ModeParseTestsFinished
 	ModeParseTests
 	ModeParseTestsFuller
+	ModeListFailedTests
+	ModeListSkippedTests
+	ModeListSlowestTests
+	ModeListDataRaces
+	ModeListBenchmarks
+	ModeListDiffs
 )
 
+// slowestTestsCount is how many tests ModeListSlowestTests shows
+const slowestTestsCount = 25
+
+// summaryLineCount is how many lines of a failure's
+// output WriteSummary shows before cutting it short
+const summaryLineCount = 10
+
+// diffColumnWidth is the most that the expected side of a
+// side-by-side diff takes up, past which its lines are cut short
+const diffColumnWidth = 60
+
 var (
 	viewBackgroundColor = tcell.NewRGBColor(0, 43, 54)
 )
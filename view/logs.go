@@ -84,10 +84,12 @@ func (c *Logs) buildLogs(mode int, checks model.CheckSuite, logs model.Logs, sel
 	if utils.ShouldShowLogs(checks.Selected) {
 		return logsDetailView(
 			logs,
+			ModeListFailedTests,
 			c.escLogsDetailHandler,
 			c.selectedHandler,
 			c.enterHandler,
 			c.selectionChangedHandler,
+			func(r rune, id int) bool { return false },
 			selectedRows...)
 	} else {
 		txtView := tview.NewTextView()
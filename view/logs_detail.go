@@ -5,7 +5,10 @@ import (
 	"github.com/aemengo/gswt/model"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
+	"math"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -19,7 +22,7 @@ type Selection struct {
 	Value int
 }
 
-func logsDetailView(logs model.Logs, escHandler func(key tcell.Key), selectedHandler func(id int), enterHandler func(), selectionChangedHandler func(txt string, row int), selections ...Selection) *tview.Table {
+func logsDetailView(logs model.Logs, listMode int, escHandler func(key tcell.Key), selectedHandler func(id int), enterHandler func(), selectionChangedHandler func(txt string, row int), keyHandler func(r rune, id int) bool, selections ...Selection) *tview.Table {
 	var (
 		row          = 0
 		rowIDMapping = map[int]int{}
@@ -31,8 +34,26 @@ func logsDetailView(logs model.Logs, escHandler func(key tcell.Key), selectedHan
 		showTitleLine(table, step, index, &row, rowIDMapping, idRowMapping)
 
 		if step.Selected {
+			switch listMode {
+			case ModeListSkippedTests:
+				showSkippedTestRuns(table, step, &row)
+				continue
+			case ModeListSlowestTests:
+				showSlowestTestRuns(table, step, &row)
+				continue
+			case ModeListDataRaces:
+				showDataRaces(table, step, &row)
+				continue
+			case ModeListBenchmarks:
+				showBenchmarks(table, step, &row)
+				continue
+			case ModeListDiffs:
+				showDiffs(table, step, &row)
+				continue
+			}
+
 			if step.IsTest() {
-				showTestSuites(table, step, &row, rowIDMapping, idRowMapping)
+				showTestResults(table, step, &row, rowIDMapping, idRowMapping)
 				continue
 			}
 
@@ -56,6 +77,8 @@ func logsDetailView(logs model.Logs, escHandler func(key tcell.Key), selectedHan
 		}
 	}
 
+	table.SetInputCapture(inputCaptureFunc(table, rowIDMapping, keyHandler))
+
 	style := tcell.StyleDefault.
 		Foreground(tcell.ColorMediumTurquoise).
 		Background(tcell.ColorDarkSlateGray).
@@ -109,15 +132,22 @@ func showTitleLine(table *tview.Table, step model.Step, index int, row *int, row
 	*row = *row + 1
 }
 
-func showTestLogLines(table *tview.Table, run model.TestRun, row *int) {
+func showTestLogLines(table *tview.Table, run model.TestRun, depth int, row *int) {
+	indent := "        " + strings.Repeat("  ", depth)
+
 	if len(run.Lines) == 0 {
+		icon, color := "✘︎", tcell.ColorIndianRed
+		if run.Rerun == model.RerunFixed {
+			icon, color = "✔︎", tcell.ColorForestGreen
+		}
+
 		table.SetCell(*row, 0,
 			tview.NewTableCell("").
 				SetSelectable(false))
 
 		table.SetCell(*row, 1,
-			tview.NewTableCell("        ✘︎").
-				SetTextColor(tcell.ColorIndianRed).
+			tview.NewTableCell(indent+icon).
+				SetTextColor(color).
 				SetSelectable(true))
 
 		*row = *row + 1
@@ -130,7 +160,16 @@ func showTestLogLines(table *tview.Table, run model.TestRun, row *int) {
 		goFileRegex     = regexp.MustCompile(`(\S+\.go:\d+:)`)
 	)
 
-	for _, line := range run.Lines {
+	for i := 0; i < len(run.Lines); i++ {
+		line := run.Lines[i]
+
+		// testify's assertion blocks are shown field by field
+		if assertion, ok := assertionAt(run, i); ok {
+			showAssertion(table, assertion, indent, row)
+			i = i + len(assertion.Lines) - 1
+			continue
+		}
+
 		txt := goFileRegex.ReplaceAllString(line, "[mediumturquoise]$1[-]")
 
 		switch {
@@ -145,7 +184,7 @@ func showTestLogLines(table *tview.Table, run model.TestRun, row *int) {
 				SetSelectable(false))
 
 		table.SetCell(*row, 1,
-			tview.NewTableCell(tview.TranslateANSI("        "+txt)).
+			tview.NewTableCell(tview.TranslateANSI(indent+txt)).
 				SetTextColor(tcell.ColorDarkGray).
 				SetSelectable(true))
 
@@ -153,13 +192,133 @@ func showTestLogLines(table *tview.Table, run model.TestRun, row *int) {
 	}
 }
 
-func showTestRuns(table *tview.Table, suite model.TestSuite, row *int, rowIDMapping map[int]int, idRowMapping map[int]int) {
-	failedTestRuns := suite.FailedTestRuns()
+func assertionAt(run model.TestRun, line int) (model.Assertion, bool) {
+	for _, assertion := range run.Assertions {
+		if assertion.Line == line {
+			return assertion, true
+		}
+	}
+
+	return model.Assertion{}, false
+}
+
+// showAssertion lays out a failed testify assertion as its error and message,
+// where it was made, and what was compared, rather than as the block of
+// labeled fields that testify prints
+func showAssertion(table *tview.Table, assertion model.Assertion, indent string, row *int) {
+	var (
+		lines      []string
+		errorLines = strings.Split(assertion.Error, "\n")
+	)
+
+	// "Not equal:" introduces what was compared, which is shown on its own
+	if len(errorLines) == 1 {
+		errorLines[0] = strings.TrimSuffix(errorLines[0], ":")
+	}
+
+	for i, line := range errorLines {
+		if i == 0 {
+			lines = append(lines, "[indianred::b]✘ "+tview.Escape(line)+"[-:-:-]")
+			continue
+		}
+
+		lines = append(lines, "  [lightgray]"+tview.Escape(line)+"[-]")
+	}
+
+	if assertion.Messages != "" {
+		for _, line := range strings.Split(assertion.Messages, "\n") {
+			lines = append(lines, "  [lightgray::i]"+tview.Escape(line)+"[-:-:-]")
+		}
+	}
+
+	for i, trace := range assertion.Trace {
+		prefix := "  at "
+		if i != 0 {
+			prefix = "     "
+		}
+
+		lines = append(lines, prefix+"[mediumturquoise::u]"+tview.Escape(trace)+"[-:-:-]")
+	}
+
+	if assertion.Expected != "" || assertion.Actual != "" {
+		lines = append(lines,
+			"  [red]expected: "+tview.Escape(assertion.Expected)+"[-]",
+			"  [green]actual  : "+tview.Escape(assertion.Actual)+"[-]")
+	}
+
+	for _, line := range assertion.Diff {
+		txt := tview.Escape(line)
+
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			txt = "[::b]" + txt + "[-:-:-]"
+		case strings.HasPrefix(line, "@@"):
+			txt = "[mediumturquoise]" + txt + "[-]"
+		case strings.HasPrefix(line, "-"):
+			txt = "[red]" + txt + "[-]"
+		case strings.HasPrefix(line, "+"):
+			txt = "[green]" + txt + "[-]"
+		}
+
+		lines = append(lines, "  "+txt)
+	}
+
+	for _, line := range lines {
+		table.SetCell(*row, 0,
+			tview.NewTableCell("").
+				SetSelectable(false))
+
+		table.SetCell(*row, 1,
+			tview.NewTableCell(indent+"    "+line).
+				SetTextColor(tcell.ColorDarkGray).
+				SetSelectable(true))
+
+		*row = *row + 1
+	}
+}
+
+// showTestRuns lists the failed runs at one level of the tree,
+// with the name of each shown relative to its parent
+func showTestRuns(table *tview.Table, failedTestRuns []model.TestRun, parentName string, depth int, row *int, rowIDMapping map[int]int, idRowMapping map[int]int) {
+	indent := "      " + strings.Repeat("  ", depth)
 
 	for _, tr := range failedTestRuns {
-		var icon = "      ► "
+		var icon = indent + "► "
 		if tr.Selected {
-			icon = "      ▼ "
+			icon = indent + "▼ "
+		}
+
+		txt := strings.ReplaceAll(strings.TrimPrefix(tr.Name, parentName+"/"), "_", " ")
+		if len(tr.TestRuns) != 0 {
+			txt = txt + fmt.Sprintf(" [darkgray](Failed: %d)[-]", tr.FailureCount())
+		}
+
+		if tr.Panicked {
+			txt = txt + " [yellow](panicked)[-]"
+		}
+
+		if len(tr.DataRaces) != 0 {
+			txt = txt + " [yellow](data race)[-]"
+		}
+
+		switch tr.Rerun {
+		case model.Rerunning:
+			txt = txt + " [yellow](rerunning)[-]"
+		case model.RerunFixed:
+			txt = txt + " [forestgreen](fixed)[-]"
+		case model.RerunStillFailing:
+			txt = txt + " [indianred](still failing)[-]"
+		}
+
+		switch {
+		case tr.Status == model.TestTimedOut && tr.Elapsed != 0:
+			txt = txt + fmt.Sprintf(" [yellow](timed out after running for %s)[-]", tr.Elapsed)
+		case tr.Status == model.TestTimedOut:
+			txt = txt + " [yellow](timed out)[-]"
+		case tr.Status == model.TestStopped:
+			txt = txt + " [yellow](stopped)[-]"
+		case tr.Elapsed != 0:
+			txt = txt + fmt.Sprintf(" [darkgray](%s)[-]", tr.Elapsed)
 		}
 
 		table.SetCell(*row, 0,
@@ -167,7 +326,7 @@ func showTestRuns(table *tview.Table, suite model.TestSuite, row *int, rowIDMapp
 				SetSelectable(false))
 
 		table.SetCell(*row, 1,
-			tview.NewTableCell(icon+strings.ReplaceAll(tr.Name, "_", " ")).
+			tview.NewTableCell(icon+txt).
 				SetTextColor(tcell.ColorLightGray).
 				SetSelectable(true))
 
@@ -176,36 +335,176 @@ func showTestRuns(table *tview.Table, suite model.TestSuite, row *int, rowIDMapp
 		*row = *row + 1
 
 		if tr.Selected {
-			showTestLogLines(table, tr, row)
+			if len(tr.Lines) != 0 || len(tr.TestRuns) == 0 {
+				showTestLogLines(table, tr, depth, row)
+			}
+
+			if tr.CorpusFile != "" {
+				showCorpusFile(table, tr, depth, row)
+			}
+
+			showTestRuns(table, tr.FailedTestRuns(), tr.Name, depth+1, row, rowIDMapping, idRowMapping)
 		}
 	}
 }
 
-func showTestSuites(table *tview.Table, step model.Step, row *int, rowIDMapping map[int]int, idRowMapping map[int]int) {
-	failedTestSuites := step.FailedTestSuites()
-	failureRegex := regexp.MustCompile(`(Failed: \d+)`)
+// showCorpusFile shows the input that a fuzz test failed on,
+// as it was read from the corpus in the directory of the test's package
+func showCorpusFile(table *tview.Table, tr model.TestRun, depth int, row *int) {
+	var (
+		indent = "        " + strings.Repeat("  ", depth)
+		lines  = []string{fmt.Sprintf("[mediumturquoise]%s[-]", tr.CorpusFile)}
+	)
+
+	if tr.CorpusError != nil {
+		lines = append(lines, fmt.Sprintf("[red]unable to read failing input: %s[-]", tview.Escape(tr.CorpusError.Error())))
+	}
+
+	for _, line := range tr.CorpusLines {
+		lines = append(lines, "[lightgray]"+tview.Escape(line)+"[-]")
+	}
 
-	if len(failedTestSuites) == 0 {
+	for _, line := range lines {
 		table.SetCell(*row, 0,
 			tview.NewTableCell("").
 				SetSelectable(false))
 
 		table.SetCell(*row, 1,
-			tview.NewTableCell("   ✔︎").
-				SetTextColor(tcell.ColorForestGreen).
+			tview.NewTableCell(indent+line).
+				SetTextColor(tcell.ColorDarkGray).
 				SetSelectable(true))
 
 		*row = *row + 1
+	}
+}
+
+// showTestResults lists the packages that go test reported on,
+// then the failures from any that it hasn't reported on yet
+func showTestResults(table *tview.Table, step model.Step, row *int, rowIDMapping map[int]int, idRowMapping map[int]int) {
+	var (
+		buildFailures []model.BuildFailure
+		testSuites    []model.TestSuite
+	)
+
+	for _, bf := range step.BuildFailures {
+		if !step.HasPackage(bf.Package) {
+			buildFailures = append(buildFailures, bf)
+		}
+	}
+
+	for _, ts := range step.FailedTestSuites() {
+		if ts.Package == "" {
+			testSuites = append(testSuites, ts)
+		}
+	}
+
+	if len(step.Packages) == 0 && len(buildFailures) == 0 && len(testSuites) == 0 {
+		showStatusLine(table, "   ✔︎", tcell.ColorForestGreen, row)
 		return
 	}
 
-	for _, ts := range failedTestSuites {
+	showPackages(table, step, row, rowIDMapping, idRowMapping)
+	showBuildFailures(table, buildFailures, 0, row, rowIDMapping, idRowMapping)
+	showTestSuites(table, testSuites, 0, row, rowIDMapping, idRowMapping)
+}
+
+// showPackages lists every package with failing ones first,
+// so that the ones that broke are seen at a glance
+func showPackages(table *tview.Table, step model.Step, row *int, rowIDMapping map[int]int, idRowMapping map[int]int) {
+	packages := append([]model.Package{}, step.Packages...)
+	sort.SliceStable(packages, func(i, j int) bool {
+		return !packages[i].Success && packages[j].Success
+	})
+
+	for _, pkg := range packages {
 		var icon = "   ► "
-		if ts.Selected {
+		if pkg.Selected {
 			icon = "   ▼ "
 		}
 
+		txt := "[forestgreen]✔︎[-] " + pkg.Name
+		if !pkg.Success {
+			txt = "[red::b]✘[-:-:-] " + pkg.Name
+		}
+
+		switch {
+		case pkg.Cached:
+			txt = txt + " [darkgray](cached)[-]"
+		case pkg.Elapsed != 0:
+			txt = txt + fmt.Sprintf(" [darkgray](%s)[-]", pkg.Elapsed)
+		}
+
+		table.SetCell(*row, 0,
+			tview.NewTableCell("").
+				SetSelectable(false))
+
+		table.SetCell(*row, 1,
+			tview.NewTableCell(icon+txt).
+				SetTextColor(tcell.ColorLightGray).
+				SetSelectable(true))
+
+		rowIDMapping[*row] = pkg.ID
+		idRowMapping[pkg.ID] = *row
+		*row = *row + 1
+
+		if !pkg.Selected {
+			continue
+		}
+
+		var (
+			buildFailures    []model.BuildFailure
+			testSuites       []model.TestSuite
+			failedBenchmarks int
+		)
+
+		for _, bf := range step.BuildFailures {
+			if bf.Package == pkg.Name {
+				buildFailures = append(buildFailures, bf)
+			}
+		}
+
+		for _, ts := range step.FailedTestSuites() {
+			if ts.Package == pkg.Name {
+				testSuites = append(testSuites, ts)
+			}
+		}
+
+		for _, bm := range step.FailedBenchmarks() {
+			if bm.Package == pkg.Name {
+				failedBenchmarks = failedBenchmarks + 1
+			}
+		}
+
+		switch {
+		case pkg.Success:
+			showStatusLine(table, "     ✔︎", tcell.ColorForestGreen, row)
+		case failedBenchmarks != 0:
+			showStatusLine(table, fmt.Sprintf("     [red::b]✘[-:-:-] %s failed [yellow](press b to list)[-]", benchmarksCount(failedBenchmarks)), tcell.ColorLightGray, row)
+		case len(buildFailures) == 0 && len(testSuites) == 0:
+			showStatusLine(table, "     [yellow](No failed tests found, press TAB to see full log)[-]", tcell.ColorDarkGray, row)
+		}
+
+		showBuildFailures(table, buildFailures, 1, row, rowIDMapping, idRowMapping)
+		showTestSuites(table, testSuites, 1, row, rowIDMapping, idRowMapping)
+	}
+}
+
+func showTestSuites(table *tview.Table, failedTestSuites []model.TestSuite, depth int, row *int, rowIDMapping map[int]int, idRowMapping map[int]int) {
+	var (
+		indent       = "   " + strings.Repeat("  ", depth)
+		failureRegex = regexp.MustCompile(`(Failed: \d+)`)
+	)
+
+	for _, ts := range failedTestSuites {
+		var icon = indent + "► "
+		if ts.Selected {
+			icon = indent + "▼ "
+		}
+
 		txt := failureRegex.ReplaceAllString(ts.Title, "[red::b]$1[-:-:-]")
+		if ts.Elapsed != 0 {
+			txt = txt + fmt.Sprintf(" (%s)", ts.Elapsed)
+		}
 
 		warn := ""
 		if !strings.Contains(ts.Title, "Failed:") {
@@ -226,23 +525,372 @@ func showTestSuites(table *tview.Table, step model.Step, row *int, rowIDMapping
 		*row = *row + 1
 
 		if ts.Selected {
-			showTestRuns(table, ts, row, rowIDMapping, idRowMapping)
+			showTestRuns(table, ts.FailedTestRuns(), ts.Name, depth, row, rowIDMapping, idRowMapping)
 		}
 	}
 }
 
-func showLogLines(table *tview.Table, step model.Step, row *int) {
-	if len(step.Lines) == 0 {
+func showBuildFailures(table *tview.Table, buildFailures []model.BuildFailure, depth int, row *int, rowIDMapping map[int]int, idRowMapping map[int]int) {
+	var (
+		indent      = "   " + strings.Repeat("  ", depth)
+		goFileRegex = regexp.MustCompile(`(\S+\.go:\d+:)`)
+	)
+
+	for _, bf := range buildFailures {
+		var icon = indent + "► "
+		if bf.Selected {
+			icon = indent + "▼ "
+		}
+
+		txt := fmt.Sprintf("# %s [red::b](build failed)[-:-:-]", bf.Package)
+		if len(bf.Diagnostics) != 0 {
+			txt = txt + fmt.Sprintf(" [darkgray](Errors: %d)[-]", len(bf.Diagnostics))
+		}
+
 		table.SetCell(*row, 0,
 			tview.NewTableCell("").
 				SetSelectable(false))
 
 		table.SetCell(*row, 1,
-			tview.NewTableCell("   ✔︎").
-				SetTextColor(tcell.ColorForestGreen).
+			tview.NewTableCell(icon+txt).
+				SetTextColor(tcell.ColorDarkGray).
 				SetSelectable(true))
 
+		rowIDMapping[*row] = bf.ID
+		idRowMapping[bf.ID] = *row
 		*row = *row + 1
+
+		if !bf.Selected {
+			continue
+		}
+
+		lines := bf.Lines
+		if len(lines) == 0 {
+			// a dependency failed to build instead
+			lines = []string{"[build failed]"}
+		}
+
+		for _, line := range lines {
+			txt := goFileRegex.ReplaceAllString(line, "[mediumturquoise]$1[-]")
+
+			table.SetCell(*row, 0,
+				tview.NewTableCell("").
+					SetSelectable(false))
+
+			table.SetCell(*row, 1,
+				tview.NewTableCell(tview.TranslateANSI(indent+"   "+txt)).
+					SetTextColor(tcell.ColorDarkGray).
+					SetSelectable(true))
+
+			*row = *row + 1
+		}
+	}
+}
+
+// showSkippedTestRuns lists every skipped test of the step,
+// followed by what it printed about why it was skipped
+func showSkippedTestRuns(table *tview.Table, step model.Step, row *int) {
+	var count int
+
+	for _, ts := range step.TestSuites {
+		for _, tr := range ts.SkippedTestRuns() {
+			showStatusLine(table, "   [yellow]↷[-] "+strings.ReplaceAll(tr.Name, "_", " "), tcell.ColorLightGray, row)
+
+			if len(tr.Lines) == 0 {
+				showStatusLine(table, "        (no reason given)", tcell.ColorDimGray, row)
+			}
+
+			for _, line := range tr.Lines {
+				showStatusLine(table, tview.TranslateANSI("        "+strings.TrimSpace(line)), tcell.ColorDarkGray, row)
+			}
+
+			count = count + 1
+		}
+	}
+
+	if count == 0 {
+		showStatusLine(table, "   No tests were skipped", tcell.ColorDimGray, row)
+	}
+}
+
+// showSlowestTestRuns lists the tests that took the longest, slowest first
+func showSlowestTestRuns(table *tview.Table, step model.Step, row *int) {
+	slowestTestRuns := step.SlowestTestRuns(slowestTestsCount)
+
+	if len(slowestTestRuns) == 0 {
+		showStatusLine(table, "   No tests have finished", tcell.ColorDimGray, row)
+		return
+	}
+
+	for _, tr := range slowestTestRuns {
+		icon := "[forestgreen]✔︎[-]"
+		switch tr.Status {
+		case model.TestFailed:
+			icon = "[red]✘[-]"
+		case model.TestSkipped:
+			icon = "[yellow]↷[-]"
+		case model.TestTimedOut, model.TestStopped:
+			icon = "[yellow]⧖[-]"
+		}
+
+		txt := fmt.Sprintf("   %s %9s  %s", icon, tr.Elapsed, strings.ReplaceAll(tr.Name, "_", " "))
+		showStatusLine(table, txt, tcell.ColorLightGray, row)
+	}
+}
+
+// showDataRaces lists each race detector report by the test it happened
+// in, with the two conflicting accesses and where their goroutines started
+func showDataRaces(table *tview.Table, step model.Step, row *int) {
+	dataRaces := step.DataRaces()
+
+	if len(dataRaces) == 0 {
+		showStatusLine(table, "   No data races were detected", tcell.ColorDimGray, row)
+		return
+	}
+
+	for _, race := range dataRaces {
+		showStatusLine(table, "   [yellow]⚠[-] DATA RACE in "+strings.ReplaceAll(race.Test, "_", " "), tcell.ColorLightGray, row)
+
+		stacks := append(append([]model.RaceStack{}, race.Accesses...), race.Goroutines...)
+
+		for _, stack := range stacks {
+			showStatusLine(table, "        "+stack.Header, tcell.ColorLightGray, row)
+
+			for _, frame := range stack.Frames {
+				txt := fmt.Sprintf("          %s [mediumturquoise]%s:%d[-]", frame.Function, frame.File, frame.Line)
+				showStatusLine(table, txt, tcell.ColorDarkGray, row)
+			}
+		}
+	}
+}
+
+// showDiffs lists the failed tests whose output has the values they
+// compared, with the expected and actual values side by side and
+// the characters that differ between them highlighted
+func showDiffs(table *tview.Table, step model.Step, row *int) {
+	runs := step.ComparedTestRuns()
+
+	if len(runs) == 0 {
+		showStatusLine(table, "   No expected and actual values were found in the failures", tcell.ColorDimGray, row)
+		return
+	}
+
+	for _, run := range runs {
+		showStatusLine(table, "   [red::b]✘[-:-:-] "+strings.ReplaceAll(run.Name, "_", " "), tcell.ColorLightGray, row)
+
+		for _, comparison := range run.Comparisons() {
+			var (
+				rows  = comparison.SideBySide()
+				width = len("expected")
+			)
+
+			for _, r := range rows {
+				if w := tview.TaggedStringWidth(tview.Escape(diffText(r.Expected))); w > width {
+					width = w
+				}
+			}
+
+			if width > diffColumnWidth {
+				width = diffColumnWidth
+			}
+
+			showStatusLine(table, fmt.Sprintf("        [::b]%s[-:-:-] │ [::b]actual[-:-:-]", diffSide(diffTextSpans("expected"), width, "", false)), tcell.ColorDarkGray, row)
+
+			for _, r := range rows {
+				// a line on both sides only has what changed highlighted
+				whole := r.Expected == nil || r.Actual == nil
+
+				txt := fmt.Sprintf("        %s [darkgray]│[-] %s", diffSide(r.Expected, width, "red", whole), diffSide(r.Actual, -1, "green", whole))
+				showStatusLine(table, txt, tcell.ColorLightGray, row)
+			}
+		}
+	}
+}
+
+// diffSide lays out one side of a line of a side-by-side diff, cut
+// short or padded to the given width unless it is -1. Changed characters
+// are highlighted in the given color, or the whole line is when the other
+// side has none.
+func diffSide(spans []model.DiffSpan, width int, color string, whole bool) string {
+	var (
+		txt  string
+		used int
+	)
+
+	for _, span := range spans {
+		// earlier spans can fill the side exactly
+		if width != -1 && used >= width {
+			break
+		}
+
+		var (
+			text = strings.ReplaceAll(span.Text, "\t", "    ")
+			cut  bool
+		)
+
+		if width != -1 {
+			text, cut = fitWidth(text, width-used)
+		}
+
+		used = used + tview.TaggedStringWidth(tview.Escape(text))
+
+		switch {
+		case whole:
+			txt = txt + "[" + color + "]" + tview.Escape(text) + "[-]"
+		case span.Changed:
+			txt = txt + "[black:" + color + "]" + tview.Escape(text) + "[-:-]"
+		default:
+			txt = txt + tview.Escape(text)
+		}
+
+		if cut {
+			return txt + "[darkgray]…[-]"
+		}
+	}
+
+	if width != -1 && used < width {
+		txt = txt + strings.Repeat(" ", width-used)
+	}
+
+	return txt
+}
+
+// fitWidth cuts the text short of the given width, leaving room for an
+// ellipsis, and reports whether it had to
+func fitWidth(text string, width int) (string, bool) {
+	if width <= 0 {
+		return "", text != ""
+	}
+
+	if tview.TaggedStringWidth(tview.Escape(text)) <= width {
+		return text, false
+	}
+
+	var (
+		fitted string
+		used   int
+	)
+
+	for _, r := range text {
+		w := tview.TaggedStringWidth(string(r))
+		if used+w > width-1 {
+			break
+		}
+
+		fitted = fitted + string(r)
+		used = used + w
+	}
+
+	return fitted + strings.Repeat(" ", width-1-used), true
+}
+
+func diffText(spans []model.DiffSpan) string {
+	var txt string
+
+	for _, span := range spans {
+		txt = txt + strings.ReplaceAll(span.Text, "\t", "    ")
+	}
+	return txt
+}
+
+func diffTextSpans(text string) []model.DiffSpan {
+	return []model.DiffSpan{{Text: text}}
+}
+
+// showBenchmarks lists every benchmark with each of its metrics, summed
+// up across the times it ran. Benchmarks that are also in a saved run
+// are listed with how much each metric changed since, the way benchstat does.
+func showBenchmarks(table *tview.Table, step model.Step, row *int) {
+	if len(step.Benchmarks) == 0 {
+		showStatusLine(table, "   No benchmarks were run", tcell.ColorDimGray, row)
+		return
+	}
+
+	for _, bm := range step.Benchmarks {
+		name := bm.Name
+		if bm.Procs != 1 {
+			name = fmt.Sprintf("%s-%d", bm.Name, bm.Procs)
+		}
+
+		if bm.Failed {
+			showStatusLine(table, "   [red::b]✘[-:-:-] "+name, tcell.ColorLightGray, row)
+
+			for _, line := range bm.Lines {
+				showStatusLine(table, tview.TranslateANSI("        "+strings.TrimSpace(line)), tcell.ColorDarkGray, row)
+			}
+			continue
+		}
+
+		baseline, ok := step.BaselineBenchmark(bm)
+		if !ok {
+			showStatusLine(table, "   [forestgreen]✔︎[-] "+name, tcell.ColorLightGray, row)
+
+			for _, unit := range bm.Units() {
+				txt := fmt.Sprintf("        %-12s %s", unit, benchmarkStat(bm.Stat(unit)))
+				showStatusLine(table, txt, tcell.ColorDarkGray, row)
+			}
+			continue
+		}
+
+		showStatusLine(table, "   [forestgreen]✔︎[-] "+name+" [darkgray](old → new)[-]", tcell.ColorLightGray, row)
+
+		for _, delta := range bm.Compare(baseline) {
+			change := "[darkgray]~[-]"
+			if delta.Significant() {
+				change = fmt.Sprintf("[yellow]%+.2f%%[-]", delta.Change*100)
+			}
+
+			txt := fmt.Sprintf("        %-12s %s  →  %s  %s [darkgray](p=%.3f n=%d+%d)[-]",
+				delta.Unit,
+				benchmarkStat(delta.Old),
+				benchmarkStat(delta.New),
+				change,
+				delta.P,
+				delta.Old.Count,
+				delta.New.Count)
+
+			showStatusLine(table, txt, tcell.ColorDarkGray, row)
+		}
+	}
+}
+
+// benchmarkStat shows a metric with about four significant digits,
+// give or take how far its samples were from one another
+func benchmarkStat(stat model.BenchmarkStat) string {
+	var value string
+
+	switch mean := stat.Mean; {
+	case mean >= 1000 || mean == math.Trunc(mean):
+		value = fmt.Sprintf("%.0f", mean)
+	case mean >= 100:
+		value = fmt.Sprintf("%.1f", mean)
+	case mean >= 10:
+		value = fmt.Sprintf("%.2f", mean)
+	case mean >= 1:
+		value = fmt.Sprintf("%.3f", mean)
+	default:
+		value = fmt.Sprintf("%.4g", mean)
+	}
+
+	return fmt.Sprintf("%10s ± %3.0f%%", value, stat.Variation*100)
+}
+
+func showStatusLine(table *tview.Table, txt string, color tcell.Color, row *int) {
+	table.SetCell(*row, 0,
+		tview.NewTableCell("").
+			SetSelectable(false))
+
+	table.SetCell(*row, 1,
+		tview.NewTableCell(txt).
+			SetTextColor(color).
+			SetSelectable(true))
+
+	*row = *row + 1
+}
+
+func showLogLines(table *tview.Table, step model.Step, row *int) {
+	if len(step.Lines) == 0 {
+		showStatusLine(table, "   ✔︎", tcell.ColorForestGreen, row)
 		return
 	}
 
@@ -291,6 +939,41 @@ func rowSelectedFunc(rowIDMapping map[int]int, selectedHandler func(id int), ent
 	}
 }
 
+// inputCaptureFunc hands letter keys to the handler, along with the id of
+// the selected row, if it has one. The keys that it doesn't use are let
+// through so that the table can still be navigated.
+func inputCaptureFunc(table *tview.Table, rowIDMapping map[int]int, keyHandler func(r rune, id int) bool) func(event *tcell.EventKey) *tcell.EventKey {
+	return func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() != tcell.KeyRune {
+			return event
+		}
+
+		row, _ := table.GetSelection()
+		if keyHandler(event.Rune(), rowIDMapping[row]) {
+			return nil
+		}
+
+		return event
+	}
+}
+
+// SourceLocation finds a file.go:57 location in the text of a row,
+// like the ones that go test prints before a test's failure messages
+func SourceLocation(txt string) (string, int, bool) {
+	var (
+		tagRegex      = regexp.MustCompile(`\[[a-zA-Z0-9_,;:#-]*\]`)
+		locationRegex = regexp.MustCompile(`([^\s():"']+\.go):(\d+)`)
+	)
+
+	matches := locationRegex.FindStringSubmatch(tagRegex.ReplaceAllString(txt, ""))
+	if len(matches) != 3 {
+		return "", 0, false
+	}
+
+	line, _ := strconv.Atoi(matches[2])
+	return matches[1], line, true
+}
+
 func selectionChangedFunc(table *tview.Table, selectionChangedHandler func(txt string, row int)) func(row, column int) {
 	return func(row, column int) {
 		txt := table.GetCell(row, column).Text
@@ -0,0 +1,93 @@
+package view
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aemengo/gswt/model"
+	"github.com/rivo/tview"
+)
+
+func TestSourceLocation(t *testing.T) {
+	tests := []struct {
+		name string
+		txt  string
+		file string
+		line int
+		ok   bool
+	}{
+		{"testing log line", "    cart_test.go:18: expected 2 items", "cart_test.go", 18, true},
+		{"color tags", "[red]    cart_test.go:18:[-] expected 2 items", "cart_test.go", 18, true},
+		{"absolute path", "        Error Trace:	/home/me/shop/cart/cart_test.go:42", "/home/me/shop/cart/cart_test.go", 42, true},
+		{"stack line", "	/home/me/shop/cart/cart.go:123 +0x45", "/home/me/shop/cart/cart.go", 123, true},
+		{"in parentheses", "panic: boom (cart.go:7)", "cart.go", 7, true},
+		{"first of several", "cart.go:7 called from cart_test.go:18", "cart.go", 7, true},
+		{"without a line", "    cart_test.go: expected 2 items", "", 0, false},
+		{"not go", "    main.rs:18: expected 2 items", "", 0, false},
+		{"no location", "--- FAIL: TestCart (0.00s)", "", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, line, ok := SourceLocation(tt.txt)
+			if file != tt.file || line != tt.line || ok != tt.ok {
+				t.Errorf("\nactual: %q %d %t\nexpected: %q %d %t", file, line, ok, tt.file, tt.line, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDiffSide(t *testing.T) {
+	prefix := strings.Repeat("a", diffColumnWidth)
+
+	tests := []struct {
+		name  string
+		spans []model.DiffSpan
+		want  string
+	}{
+		{"pads a short line", []model.DiffSpan{{Text: "abc"}}, "abc" + strings.Repeat(" ", diffColumnWidth-3)},
+		{"fills the side exactly", []model.DiffSpan{{Text: prefix}}, prefix},
+		{"cuts a long line short", []model.DiffSpan{{Text: prefix + "b"}}, prefix[1:] + "[darkgray]…[-]"},
+		{"drops the spans after a full side", []model.DiffSpan{{Text: prefix}, {Text: "b", Changed: true}, {Text: "c"}}, prefix},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffSide(tt.spans, diffColumnWidth, "red", false)
+			if got != tt.want {
+				t.Errorf("\nactual: %q\nexpected: %q", got, tt.want)
+			}
+
+			if w := tview.TaggedStringWidth(got); w != diffColumnWidth {
+				t.Errorf("expected a width of %d, got %d", diffColumnWidth, w)
+			}
+		})
+	}
+}
+
+func TestShowDiffsOfLongLines(t *testing.T) {
+	var (
+		prefix = strings.Repeat("a", diffColumnWidth)
+		table  = tview.NewTable()
+		row    int
+		step   = model.Step{
+			TestSuites: []model.TestSuite{{
+				TestRuns: []model.TestRun{{
+					Name:   "TestLong",
+					Status: model.TestFailed,
+					Lines: []string{
+						"    long_test.go:10: values differ",
+						"        want: " + prefix + "bcd",
+						"        got: " + prefix + "xyz",
+					},
+				}},
+			}},
+		}
+	)
+
+	showDiffs(table, step, &row)
+
+	if row < 3 {
+		t.Fatalf("expected the values to be listed, got %d rows", row)
+	}
+}
@@ -0,0 +1,205 @@
+package view
+
+import (
+	"fmt"
+	"github.com/aemengo/gswt/model"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WriteSummary prints a plain text report of what failed, for when
+// there is no terminal to show the UI in, like in a CI log
+func WriteSummary(w io.Writer, logs model.Logs, duration time.Duration) error {
+	var b strings.Builder
+
+	for _, step := range logs {
+		writeStepSummary(&b, step)
+	}
+
+	b.WriteString(fmt.Sprintf("\n%s (%s)\n", summaryTotals(logs), duration))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeStepSummary lists the failed packages with the failures in each,
+// then the failures from any package that go test didn't report on
+func writeStepSummary(b *strings.Builder, step model.Step) {
+	packages := append([]model.Package{}, step.Packages...)
+	sort.SliceStable(packages, func(i, j int) bool {
+		return !packages[i].Success && packages[j].Success
+	})
+
+	for _, pkg := range packages {
+		if pkg.Success {
+			continue
+		}
+
+		txt := "✘ " + pkg.Name
+
+		switch {
+		case hasBuildFailure(step, pkg.Name):
+			txt = txt + " (build failed)"
+		case pkg.Elapsed != 0:
+			txt = txt + fmt.Sprintf(" (%s)", pkg.Elapsed)
+		}
+
+		b.WriteString(txt + "\n")
+
+		for _, bf := range step.BuildFailures {
+			if bf.Package == pkg.Name {
+				writeSummaryLines(b, bf.Lines, "      ")
+			}
+		}
+
+		for _, ts := range step.FailedTestSuites() {
+			if ts.Package == pkg.Name {
+				writeTestSuiteSummary(b, ts, "  ")
+			}
+		}
+
+		for _, bm := range step.FailedBenchmarks() {
+			if bm.Package == pkg.Name {
+				b.WriteString(fmt.Sprintf("  ✘ %s\n", bm.Name))
+				writeSummaryLines(b, bm.Lines, "      ")
+			}
+		}
+	}
+
+	for _, bf := range step.BuildFailures {
+		if !step.HasPackage(bf.Package) {
+			b.WriteString(fmt.Sprintf("✘ %s (build failed)\n", bf.Package))
+			writeSummaryLines(b, bf.Lines, "      ")
+		}
+	}
+
+	for _, ts := range step.FailedTestSuites() {
+		if ts.Package == "" {
+			writeTestSuiteSummary(b, ts, "")
+		}
+	}
+
+	if step.HaveUnhandledFailures() {
+		b.WriteString("Some failures may not be showing, see the full log for them\n")
+	}
+}
+
+func hasBuildFailure(step model.Step, pkg string) bool {
+	for _, bf := range step.BuildFailures {
+		if bf.Package == pkg {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeTestSuiteSummary only has a line for the suite when it is more than
+// the test it is named after, like the suites of a spec test are. Otherwise
+// the run of that test has the line, with the subtests listed under it.
+func writeTestSuiteSummary(b *strings.Builder, ts model.TestSuite, indent string) {
+	if !strings.HasPrefix(ts.Title, ts.Name+" (") {
+		b.WriteString(fmt.Sprintf("%s✘ %s\n", indent, ts.Title))
+		writeTestRunSummary(b, ts.FailedTestRuns(), ts.Name, indent+"  ")
+		return
+	}
+
+	var mainTestRuns, testRuns []model.TestRun
+	for _, tr := range ts.FailedTestRuns() {
+		if tr.Name == ts.Name {
+			mainTestRuns = append(mainTestRuns, tr)
+		} else {
+			testRuns = append(testRuns, tr)
+		}
+	}
+
+	writeTestRunSummary(b, mainTestRuns, "", indent)
+
+	if len(mainTestRuns) != 0 {
+		indent = indent + "  "
+	}
+
+	writeTestRunSummary(b, testRuns, ts.Name, indent)
+}
+
+// writeTestRunSummary lists the failed runs at one level of the tree,
+// with the output of the ones that failed on their own
+func writeTestRunSummary(b *strings.Builder, failedTestRuns []model.TestRun, parentName string, indent string) {
+	for _, tr := range failedTestRuns {
+		txt := indent + "✘ " + strings.TrimPrefix(tr.Name, parentName+"/")
+
+		switch {
+		case tr.Panicked:
+			txt = txt + " (panicked)"
+		case tr.Status == model.TestTimedOut:
+			txt = txt + " (timed out)"
+		case tr.Status == model.TestStopped:
+			txt = txt + " (stopped)"
+		}
+
+		if tr.Elapsed != 0 {
+			txt = txt + fmt.Sprintf(" (%s)", tr.Elapsed)
+		}
+
+		b.WriteString(txt + "\n")
+
+		failedTestRuns := tr.FailedTestRuns()
+		if len(failedTestRuns) == 0 {
+			writeSummaryLines(b, tr.Lines, indent+"    ")
+		}
+
+		writeTestRunSummary(b, failedTestRuns, tr.Name, indent+"  ")
+	}
+}
+
+// writeSummaryLines writes the start of some output, without
+// its colors or blank lines, up to summaryLineCount of them
+func writeSummaryLines(b *strings.Builder, lines []string, indent string) {
+	var (
+		count     int
+		ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	)
+
+	for _, line := range lines {
+		line = strings.TrimRight(ansiRegex.ReplaceAllString(line, ""), " \t")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if count == summaryLineCount {
+			b.WriteString(indent + "...\n")
+			return
+		}
+
+		b.WriteString(indent + strings.TrimPrefix(line, "    ") + "\n")
+		count = count + 1
+	}
+}
+
+func summaryTotals(logs model.Logs) string {
+	txt := "1 test"
+	if count := logs.TestCount(); count != 1 {
+		txt = fmt.Sprintf("%d tests", count)
+	}
+
+	if failed := logs.FailureCount(); failed != 0 {
+		txt = txt + fmt.Sprintf(", %d failed", failed)
+	}
+
+	if skipped := logs.SkipCount(); skipped != 0 {
+		txt = txt + fmt.Sprintf(", %d skipped", skipped)
+	}
+
+	if count := logs.BuildFailureCount(); count != 0 {
+		txt = txt + fmt.Sprintf(", %s failed to build", packagesCount(count))
+	}
+
+	if logs.Failed() {
+		return "FAIL: " + txt
+	}
+
+	return "PASS: " + txt
+}
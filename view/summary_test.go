@@ -0,0 +1,82 @@
+package view
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aemengo/gswt/model"
+)
+
+func TestWriteTestSuiteSummary(t *testing.T) {
+	var (
+		failed = func(name string, lines []string, testRuns ...model.TestRun) model.TestRun {
+			return model.TestRun{Name: name, Status: model.TestFailed, Lines: lines, TestRuns: testRuns}
+		}
+		passed = model.TestRun{Name: "TestBoom/good", Status: model.TestPassed}
+	)
+
+	tests := []struct {
+		name  string
+		suite model.TestSuite
+		want  []string
+	}{
+		{
+			"test without subtests",
+			model.TestSuite{
+				Title:    "TestPlain (Passed: 0 | Failed: 1 | Skipped: 0)",
+				Name:     "TestPlain",
+				TestRuns: []model.TestRun{failed("TestPlain", []string{"    plain_test.go:13: plain fail"})},
+			},
+			[]string{
+				"  ✘ TestPlain",
+				"      plain_test.go:13: plain fail",
+			},
+		},
+		{
+			"subtests under their test",
+			model.TestSuite{
+				Title: "TestBoom (Passed: 1 | Failed: 2 | Skipped: 0)",
+				Name:  "TestBoom",
+				TestRuns: []model.TestRun{
+					failed("TestBoom", nil),
+					failed("TestBoom/bad", nil, failed("TestBoom/bad/deeper", []string{"    boom_test.go:7: deep fail"})),
+					passed,
+					failed("TestBoom/worse", []string{"    boom_test.go:10: worse fail"}),
+				},
+			},
+			[]string{
+				"  ✘ TestBoom",
+				"    ✘ bad",
+				"      ✘ deeper",
+				"          boom_test.go:7: deep fail",
+				"    ✘ worse",
+				"        boom_test.go:10: worse fail",
+			},
+		},
+		{
+			"suite of a spec test",
+			model.TestSuite{
+				Title:    "Cart Suite (Passed: 0 | Failed: 1 | Skipped: 0)",
+				Name:     "TestCart",
+				TestRuns: []model.TestRun{failed("TestCart/adds_items", []string{"    cart_test.go:18: add fail"})},
+			},
+			[]string{
+				"  ✘ Cart Suite (Passed: 0 | Failed: 1 | Skipped: 0)",
+				"    ✘ adds_items",
+				"        cart_test.go:18: add fail",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			writeTestSuiteSummary(&b, tt.suite, "  ")
+
+			want := strings.Join(tt.want, "\n") + "\n"
+			if got := b.String(); got != want {
+				t.Errorf("\nactual:\n%s\nexpected:\n%s", got, want)
+			}
+		})
+	}
+}
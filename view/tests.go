@@ -13,8 +13,12 @@ type Tests struct {
 	enterHandler            func()
 	selectedHandler         func(id int)
 	selectionChangedHandler func(txt string, row int)
+	keyHandler              func(r rune, id int) bool
 	statusBar               *tview.TextView
 	detailTV                *tview.TextView
+
+	// message is shown in the status bar until it is replaced
+	message string
 }
 
 func NewTests() *Tests {
@@ -23,12 +27,13 @@ func NewTests() *Tests {
 		enterHandler:            func() {},
 		selectedHandler:         func(id int) {},
 		selectionChangedHandler: func(txt string, row int) {},
+		keyHandler:              func(r rune, id int) bool { return false },
 	}
 }
 
-func (v *Tests) Load(app *tview.Application, logs model.Logs, mode int, displayMode int, testDuration time.Duration, detailText string, selectedRows ...Selection) {
+func (v *Tests) Load(app *tview.Application, logs model.Logs, mode int, displayMode int, listMode int, testDuration time.Duration, detailText string, selectedRows ...Selection) {
 	statusBar := v.buildStatusBar()
-	table := v.buildTestsTable(logs, displayMode, selectedRows...)
+	table := v.buildTestsTable(logs, listMode, selectedRows...)
 
 	flex := tview.NewFlex().
 		SetDirection(tview.FlexRow).
@@ -51,11 +56,22 @@ func (v *Tests) Load(app *tview.Application, logs model.Logs, mode int, displayM
 	app.SetRoot(flex, true)
 }
 
-func (v *Tests) SetHandlers(escHandler func(key tcell.Key), enterHandler func(), selectedHandler func(id int), selectionChangedHandler func(txt string, row int)) {
+func (v *Tests) SetHandlers(escHandler func(key tcell.Key), enterHandler func(), selectedHandler func(id int), selectionChangedHandler func(txt string, row int), keyHandler func(r rune, id int) bool) {
 	v.escHandler = escHandler
 	v.enterHandler = enterHandler
 	v.selectedHandler = selectedHandler
 	v.selectionChangedHandler = selectionChangedHandler
+	v.keyHandler = keyHandler
+}
+
+// SetMessage shows txt at the start of the status bar,
+// for when it is next updated
+func (v *Tests) SetMessage(txt string) {
+	if txt != "" {
+		txt = txt + " "
+	}
+
+	v.message = txt
 }
 
 func (v *Tests) UpdateStatus(mode int, logs model.Logs, duration time.Duration) {
@@ -64,13 +80,25 @@ func (v *Tests) UpdateStatus(mode int, logs model.Logs, duration time.Duration)
 	}
 
 	if mode == ModeParseTestsRunning {
-		v.statusBar.SetText(fmt.Sprintf("Running %s... (%s)", testsCount(logs), duration))
+		v.statusBar.SetText(fmt.Sprintf("%sRunning %s... (%s)", v.message, testsCount(logs), duration))
 	} else {
-		warn := ""
+		warn := v.message
 		if logs.HaveUnhandledFailures() {
 			warn = "[yellow](Some failures may not be showing, press TAB to see full log)[-]"
 		}
 
+		if count := logs.BenchmarkCount(); count != 0 {
+			warn = warn + fmt.Sprintf("(%s, press b to list)", benchmarksCount(count))
+		}
+
+		if count := logs.DataRaceCount(); count != 0 {
+			warn = warn + fmt.Sprintf("[yellow](%s, press r to list)[-]", dataRacesCount(count))
+		}
+
+		if count := logs.BuildFailureCount(); count != 0 {
+			warn = warn + fmt.Sprintf("[red::b](%s failed to build)[-:-:-]", packagesCount(count))
+		}
+
 		v.statusBar.SetText(fmt.Sprintf("%s Completed %s! (%s)", warn, testsCount(logs), duration))
 	}
 }
@@ -101,21 +129,55 @@ func (v *Tests) buildDetailTextView() *tview.TextView {
 	return tv
 }
 
-func (v *Tests) buildTestsTable(logs model.Logs, mode int, selectedRows ...Selection) *tview.Table {
+func (v *Tests) buildTestsTable(logs model.Logs, listMode int, selectedRows ...Selection) *tview.Table {
 	return logsDetailView(
 		logs,
+		listMode,
 		v.escHandler,
 		v.selectedHandler,
 		v.enterHandler,
 		v.selectionChangedHandler,
+		v.keyHandler,
 		selectedRows...)
 }
 
-func testsCount(logs model.Logs) string {
-	count := logs.TestCount()
+func benchmarksCount(count int) string {
+	if count == 1 {
+		return "1 benchmark"
+	}
+
+	return fmt.Sprintf("%d benchmarks", count)
+}
+
+func dataRacesCount(count int) string {
+	if count == 1 {
+		return "1 data race"
+	}
+
+	return fmt.Sprintf("%d data races", count)
+}
+
+func packagesCount(count int) string {
 	if count == 1 {
-		return "1 test"
+		return "1 package"
+	}
+
+	return fmt.Sprintf("%d packages", count)
+}
+
+func testsCount(logs model.Logs) string {
+	var (
+		txt   = "1 test"
+		count = logs.TestCount()
+	)
+
+	if count != 1 {
+		txt = fmt.Sprintf("%d tests", count)
+	}
+
+	if skipped := logs.SkipCount(); skipped != 0 {
+		txt = txt + fmt.Sprintf(" [yellow](%d skipped, press s to list)[-]", skipped)
 	}
 
-	return fmt.Sprintf("%d tests", count)
+	return txt
 }